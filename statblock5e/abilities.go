@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ability is a single ability score with its derived modifier and saving
+// throw bonus.
+type Ability struct {
+	Score    int
+	Modifier int
+	Save     int
+}
+
+// NewAbility computes the modifier for score. The save bonus starts out equal
+// to the modifier.
+func NewAbility(score int) Ability {
+	mod := score/2 - 5
+	return Ability{Score: score, Modifier: mod, Save: mod}
+}
+
+// ParseAbility parses a raw score such as "15".
+func ParseAbility(s string) (Ability, error) {
+	s = strings.TrimSpace(s)
+	score, err := strconv.Atoi(s)
+	if err != nil || score < 0 {
+		return NewAbility(10), fmt.Errorf("Invalid ability score %q", s)
+	}
+	return NewAbility(score), nil
+}
+
+// String formats the ability the way the books print it, e.g. "15 (+2)".
+func (a Ability) String() string {
+	return fmt.Sprintf("%d (%s)", a.Score, formatModifier(a.Modifier))
+}
+
+// formatModifier prints a bonus with its sign. Negative values use an en dash,
+// which is more visible than a hyphen.
+func formatModifier(i int) string {
+	if i < 0 {
+		return fmt.Sprintf("–%d", -i)
+	}
+	return fmt.Sprintf("+%d", i)
+}
+
+// AbilityNames lists the abilities in stat block order.
+var AbilityNames = []string{"Str", "Dex", "Con", "Int", "Wis", "Cha"}
+
+// abilityName returns the short name for a short or full ability name, or ""
+// if the name is unknown.
+func abilityName(s string) string {
+	s = strings.TrimSpace(s)
+	for _, name := range AbilityNames {
+		if len(s) >= 3 && strings.EqualFold(name, s[0:3]) {
+			return name
+		}
+	}
+	return ""
+}
+
+type AbilityScores struct {
+	Str Ability
+	Dex Ability
+	Con Ability
+	Int Ability
+	Wis Ability
+	Cha Ability
+}
+
+// Get returns the ability for a short ("Dex") or full ("Dexterity") name, or
+// nil if the name is unknown.
+func (a *AbilityScores) Get(name string) *Ability {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 3 {
+		return nil
+	}
+	switch name[0:3] {
+	case "str":
+		return &a.Str
+	case "dex":
+		return &a.Dex
+	case "con":
+		return &a.Con
+	case "int":
+		return &a.Int
+	case "wis":
+		return &a.Wis
+	case "cha":
+		return &a.Cha
+	}
+	return nil
+}
+
+// parseAbilities fills in m.Abilities from the raw score fields.
+func (m *Monster) parseAbilities() []error {
+	var errs []error
+	raw := []string{m.Str, m.Dex, m.Con, m.Int, m.Wis, m.Cha}
+	for i, name := range AbilityNames {
+		a, err := ParseAbility(raw[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", name, err))
+		}
+		*m.Abilities.Get(name) = a
+	}
+	return errs
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAbility(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Ability
+		text string
+		err  bool
+	}{
+		{"1", Ability{1, -5, -5}, "1 (–5)", false},
+		{"10", Ability{10, 0, 0}, "10 (+0)", false},
+		{"11", Ability{11, 0, 0}, "11 (+0)", false},
+		{" 8 ", Ability{8, -1, -1}, "8 (–1)", false},
+		{"30", Ability{30, 10, 10}, "30 (+10)", false},
+		{"", Ability{10, 0, 0}, "10 (+0)", true},
+		{"abc", Ability{10, 0, 0}, "10 (+0)", true},
+		{"-3", Ability{10, 0, 0}, "10 (+0)", true},
+	}
+	for _, tt := range tests {
+		a, err := ParseAbility(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseAbility(%q) error %v, want error %v", tt.in, err, tt.err)
+		}
+		if a != tt.want {
+			t.Errorf("ParseAbility(%q) = %+v, want %+v", tt.in, a, tt.want)
+		}
+		if s := a.String(); s != tt.text {
+			t.Errorf("ParseAbility(%q).String() = %q, want %q", tt.in, s, tt.text)
+		}
+	}
+}
+
+func TestAbilityNames(t *testing.T) {
+	var scores AbilityScores
+	for _, name := range []string{"Str", "dexterity", " CON ", "Intelligence", "wis", "Charisma"} {
+		if scores.Get(name) == nil || abilityName(name) == "" {
+			t.Errorf("Ability %q not found", name)
+		}
+	}
+	for _, name := range []string{"", "St", "Luck"} {
+		if scores.Get(name) != nil || abilityName(name) != "" {
+			t.Errorf("Ability %q found", name)
+		}
+	}
+}
+
+func TestAbilityWarnings(t *testing.T) {
+	m := &Monster{Name: "Blob", Str: "12", Dex: "", Con: "abc", Int: "1", Wis: "10", Cha: "30"}
+	errs := m.parseAbilities()
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{`Dex: Invalid ability score ""`, `Con: Invalid ability score "abc"`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("parseAbilities errors %q, want %q", got, want)
+	}
+	if m.Abilities.Str.Modifier != 1 || m.Abilities.Con.Score != 10 || m.Abilities.Cha.Modifier != 10 {
+		t.Errorf("Abilities %+v", m.Abilities)
+	}
+
+	// Loading logs them as warnings.
+	dir := writeTestData(t, map[string]string{"Blobs.xml": `<compendium><monster><name>Blob</name>` +
+		`<str>12</str><dex>14</dex><con>abc</con><int>1</int><wis>10</wis><cha>8</cha><cr>1</cr>` +
+		`<ac>10</ac><hp>5 (1d8+1)</hp><speed>10 ft.</speed></monster></compendium>`})
+	var b bytes.Buffer
+	log.SetOutput(&b)
+	defer log.SetOutput(os.Stderr)
+	if _, err := LoadCompendium(filepath.Join(dir, "data", "Blobs.xml")); err != nil {
+		t.Fatal(err)
+	}
+	if want := `WARNING: Monster "Blob" in "Blobs": Con: Invalid ability score "abc"`; !strings.Contains(b.String(), want) {
+		t.Errorf("Log %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ArmorClass is the parsed form of an AC entry such as
+// "17 (natural armor, shield)" or "12 (15 with mage armor)".
+type ArmorClass struct {
+	Value       int
+	Sources     []string
+	Conditional []ConditionalArmorClass
+}
+
+// ConditionalArmorClass is an alternate AC that applies under a condition,
+// e.g. 15 with mage armor.
+type ConditionalArmorClass struct {
+	Value     int
+	Condition string
+}
+
+var (
+	acRe            = regexp.MustCompile(`^(\d+)\s*(?:\((.*)\))?\s*(.*)$`)
+	conditionalAcRe = regexp.MustCompile(`^(\d+)\s+(with|while|in)\s+(.+)$`)
+)
+
+// ParseArmorClass parses the Lion's Den ac text.
+func ParseArmorClass(s string) (ArmorClass, error) {
+	s = strings.TrimSpace(s)
+	p := acRe.FindStringSubmatch(s)
+	if p == nil {
+		return ArmorClass{}, fmt.Errorf("Invalid armor class %q", s)
+	}
+	ac := ArmorClass{}
+	ac.Value, _ = strconv.Atoi(p[1])
+
+	rest := p[2]
+	if p[3] != "" {
+		rest += "," + p[3]
+	}
+	for _, src := range strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ';' }) {
+		src = strings.Trim(strings.TrimSpace(src), "()")
+		if src == "" {
+			continue
+		}
+		if c := conditionalAcRe.FindStringSubmatch(src); c != nil {
+			v, _ := strconv.Atoi(c[1])
+			cond := c[3]
+			if c[2] != "with" {
+				cond = c[2] + " " + cond
+			}
+			ac.Conditional = append(ac.Conditional, ConditionalArmorClass{Value: v, Condition: cond})
+			continue
+		}
+		ac.Sources = append(ac.Sources, src)
+	}
+	return ac, nil
+}
+
+// Short returns the AC for the tracker table, e.g. "12 (15)" when there is
+// a conditional AC.
+func (ac ArmorClass) Short() string {
+	s := strconv.Itoa(ac.Value)
+	if len(ac.Conditional) > 0 {
+		alt := make([]string, len(ac.Conditional))
+		for i, c := range ac.Conditional {
+			alt[i] = strconv.Itoa(c.Value)
+		}
+		s += " (" + strings.Join(alt, ", ") + ")"
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArmorClass(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  ArmorClass
+		short string
+		err   bool
+	}{
+		{in: "12 (15 with mage armor)", want: ArmorClass{Value: 12,
+			Conditional: []ConditionalArmorClass{{15, "mage armor"}}}, short: "12 (15)"},
+		{in: "17 (natural armor, shield)", want: ArmorClass{Value: 17,
+			Sources: []string{"natural armor", "shield"}}, short: "17"},
+		{in: "15", want: ArmorClass{Value: 15}, short: "15"},
+		{in: " 13 (natural armor), 11 while prone", want: ArmorClass{Value: 13, Sources: []string{"natural armor"},
+			Conditional: []ConditionalArmorClass{{11, "while prone"}}}, short: "13 (11)"},
+		{in: "18 (plate; 20 with shield)", want: ArmorClass{Value: 18, Sources: []string{"plate"},
+			Conditional: []ConditionalArmorClass{{20, "shield"}}}, short: "18 (20)"},
+		{in: "", err: true},
+		{in: "natural armor", err: true},
+	}
+	for _, tt := range tests {
+		ac, err := ParseArmorClass(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseArmorClass(%q) = %+v, want an error", tt.in, ac)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseArmorClass(%q): %s", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(ac, tt.want) {
+			t.Errorf("ParseArmorClass(%q) = %+v, want %+v", tt.in, ac, tt.want)
+		}
+		if s := ac.Short(); s != tt.short {
+			t.Errorf("ParseArmorClass(%q).Short() = %q, want %q", tt.in, s, tt.short)
+		}
+	}
+}
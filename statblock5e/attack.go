@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Attack is a parsed Lion's Den attack string of the form
+// "Name|+5|1d8+3".
+type Attack struct {
+	Name string
+	// ToHit is nil for attacks without an attack roll, such as breath
+	// weapons.
+	ToHit      *int
+	Damage     Dice
+	DamageType string
+}
+
+// ParseAttack parses a single Lion's Den attack string.
+func ParseAttack(s string) (Attack, error) {
+	p := strings.Split(s, "|")
+	if len(p) != 3 {
+		return Attack{}, fmt.Errorf("Invalid attack %q", s)
+	}
+	a := Attack{Name: strings.TrimSpace(p[0])}
+	if hit := strings.TrimSpace(p[1]); hit != "" {
+		i, err := strconv.Atoi(strings.TrimPrefix(hit, "+"))
+		if err != nil {
+			return a, fmt.Errorf("Invalid attack bonus in %q", s)
+		}
+		a.ToHit = &i
+	}
+	if dmg := strings.TrimSpace(p[2]); dmg != "" {
+		d, err := ParseDice(dmg)
+		if err != nil {
+			return a, fmt.Errorf("Invalid attack damage in %q: %s", s, err)
+		}
+		a.Damage = d
+	}
+	return a, nil
+}
+
+// AverageDamage returns the average damage of a hit.
+func (a Attack) AverageDamage() int {
+	return a.Damage.Average()
+}
+
+var damageTextRe = regexp.MustCompile(`(\d+)(?:\s*\(([^)]+)\))?\s+(` + strings.Join(DamageTypes, "|") + `)\s+damage`)
+
+// damageInText returns each damage roll and type mentioned in text, such as
+// "11 (2d8 + 2) piercing damage".
+func damageInText(text string) ([]Dice, []string) {
+	var dice []Dice
+	var types []string
+	for _, p := range damageTextRe.FindAllStringSubmatch(text, -1) {
+		d, err := ParseDice(p[2])
+		if p[2] == "" || err != nil {
+			d, _ = ParseDice(p[1])
+		}
+		dice = append(dice, d)
+		types = append(types, p[3])
+	}
+	return dice, types
+}
+
+// parse fills in the usage limits, structured attacks and damage types of
+// the trait.
+func (t *Trait) parse() []error {
+	var errs []error
+	t.parseUsage()
+	dice, types := damageInText(strings.Join(t.Text, "\n"))
+	t.DamageTypes = nil
+	for _, dt := range types {
+		if !isOneOf(dt, t.DamageTypes) {
+			t.DamageTypes = append(t.DamageTypes, dt)
+		}
+	}
+	t.Attacks = nil
+	for _, s := range t.Attack {
+		a, err := ParseAttack(s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for i, d := range dice {
+			if d == a.Damage {
+				a.DamageType = types[i]
+				break
+			}
+		}
+		if a.DamageType == "" && len(t.DamageTypes) == 1 {
+			a.DamageType = t.DamageTypes[0]
+		}
+		t.Attacks = append(t.Attacks, a)
+	}
+	return errs
+}
+
+// parseTraits parses the attacks in every trait, action, reaction and
+// legendary action.
+func (m *Monster) parseTraits() []error {
+	var errs []error
+	for _, traits := range [][]Trait{m.Traits, m.Actions, m.Reactions, m.Legendary} {
+		for i := range traits {
+			for _, err := range traits[i].parse() {
+				errs = append(errs, fmt.Errorf("%s: %s", traits[i].Name, err))
+			}
+		}
+	}
+	return errs
+}
+
+var toHitTextRe = regexp.MustCompile(`([+\-–]\s*\d+) to hit`)
+
+// attacksFromText builds Lion's Den attack strings from the attack bonus and
+// damage rolls in the text, for formats that only have the prose.
+func (t *Trait) attacksFromText() {
+	text := strings.Join(t.Text, "\n")
+	hit := ""
+	if p := toHitTextRe.FindStringSubmatch(text); p != nil {
+		hit = strings.Replace(strings.Replace(p[1], "–", "-", 1), " ", "", -1)
+	}
+	name := strings.TrimSpace(parenRe.ReplaceAllString(t.Name, ""))
+	for _, p := range damageTextRe.FindAllStringSubmatch(text, -1) {
+		dice := p[2]
+		if dice == "" {
+			dice = p[1]
+		}
+		t.Attack = append(t.Attack, name+"|"+hit+"|"+strings.Replace(dice, " ", "", -1))
+		hit = ""
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAttack(t *testing.T) {
+	tests := []struct {
+		in     string
+		name   string
+		toHit  *int
+		damage string
+		err    bool
+	}{
+		{in: "Scimitar|+4|1d6+2", name: "Scimitar", toHit: intPtr(4), damage: "1d6+2"},
+		{in: "Bite|-1|1d4", name: "Bite", toHit: intPtr(-1), damage: "1d4"},
+		{in: "Fire Breath||18d6", name: "Fire Breath", damage: "18d6"},
+		{in: "Slam|+5|", name: "Slam", toHit: intPtr(5)},
+		{in: "Slam|+5", err: true},
+		{in: "Slam|five|1d6", err: true},
+		{in: "Slam|+5|lots", err: true},
+	}
+	for _, tt := range tests {
+		a, err := ParseAttack(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseAttack(%q) = %+v, want an error", tt.in, a)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAttack(%q): %s", tt.in, err)
+			continue
+		}
+		damage := ""
+		if a.Damage != (Dice{}) {
+			damage = a.Damage.String()
+		}
+		if a.Name != tt.name || !reflect.DeepEqual(a.ToHit, tt.toHit) || damage != tt.damage {
+			t.Errorf("ParseAttack(%q) = %+v, want %q, %v, %q", tt.in, a, tt.name, tt.toHit, tt.damage)
+		}
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestTraitAttackDamageTypes(t *testing.T) {
+	tr := Trait{
+		Name:   "Longsword",
+		Text:   []string{"Melee Weapon Attack: +5 to hit, reach 5 ft., one target. Hit: 7 (1d8 + 3) slashing damage, or 8 (1d10 + 3) slashing damage if used with two hands, plus 3 (1d6) fire damage."},
+		Attack: []string{"Longsword|+5|1d8+3", "Longsword (two hands)|+5|1d10+3", "Flame|+5|1d6"},
+	}
+	if errs := tr.parse(); len(errs) > 0 {
+		t.Fatalf("parse: %v", errs)
+	}
+	if want := []string{"slashing", "fire"}; !reflect.DeepEqual(tr.DamageTypes, want) {
+		t.Errorf("DamageTypes = %q, want %q", tr.DamageTypes, want)
+	}
+	var types []string
+	for _, a := range tr.Attacks {
+		types = append(types, a.DamageType)
+	}
+	if want := []string{"slashing", "slashing", "fire"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("Attack damage types = %q, want %q", types, want)
+	}
+	if avg := tr.Attacks[0].AverageDamage(); avg != 7 {
+		t.Errorf("AverageDamage = %d, want 7", avg)
+	}
+}
+
+func TestAttacksFromText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"Scimitar", "Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 5 (1d6 + 2) slashing damage.",
+			[]string{"Scimitar|+4|1d6+2"}},
+		{"Bite", "Melee Weapon Attack: –1 to hit, reach 5 ft., one target. Hit: 1 piercing damage.",
+			[]string{"Bite|-1|1"}},
+		{"Fire Breath (Recharge 5–6)", "Each creature must make a DC 21 Dexterity saving throw, taking 63 (18d6) fire damage on a failed save.",
+			[]string{"Fire Breath||18d6"}},
+		{"Claw", "Melee Weapon Attack: +6 to hit. Hit: 8 (1d8 + 4) slashing damage plus 7 (2d6) poison damage.",
+			[]string{"Claw|+6|1d8+4", "Claw||2d6"}},
+		{"Multiattack", "The dragon makes three attacks.", nil},
+	}
+	for _, tt := range tests {
+		tr := Trait{Name: tt.name, Text: []string{tt.text}}
+		tr.attacksFromText()
+		if !reflect.DeepEqual(tr.Attack, tt.want) {
+			t.Errorf("attacksFromText(%q) = %q, want %q", tt.name, tr.Attack, tt.want)
+		}
+	}
+}
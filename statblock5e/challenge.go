@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChallengeRating is a numeric challenge rating. Fractional ratings are
+// stored as 0.125, 0.25 and 0.5.
+type ChallengeRating float64
+
+var fractionalCr = map[string]ChallengeRating{
+	"1/8": 0.125,
+	"1/4": 0.25,
+	"1/2": 0.5,
+}
+
+// xpByCr is the experience point table indexed by whole challenge rating.
+var xpByCr = []int{
+	10, 200, 450, 700, 1100, 1800, 2300, 2900, 3900, 5000,
+	5900, 7200, 8400, 10000, 11500, 13000, 15000, 18000, 20000, 22000,
+	25000, 33000, 41000, 50000, 62000, 75000, 90000, 105000, 120000, 135000,
+	155000,
+}
+
+// ParseChallengeRating parses values like "1/4", "2" or "30".
+func ParseChallengeRating(s string) (ChallengeRating, error) {
+	s = strings.TrimSpace(s)
+	if cr, ok := fractionalCr[s]; ok {
+		return cr, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 || i >= len(xpByCr) {
+		return 0, fmt.Errorf("Invalid challenge rating %q", s)
+	}
+	return ChallengeRating(i), nil
+}
+
+// XP returns the experience points for defeating a creature of this rating.
+func (cr ChallengeRating) XP() int {
+	switch cr {
+	case 0.125:
+		return 25
+	case 0.25:
+		return 50
+	case 0.5:
+		return 100
+	}
+	i := int(cr)
+	if i < 0 || i >= len(xpByCr) {
+		return 0
+	}
+	return xpByCr[i]
+}
+
+// ProficiencyBonus returns the proficiency bonus for this rating.
+func (cr ChallengeRating) ProficiencyBonus() int {
+	if cr < 1 {
+		return 2
+	}
+	return 2 + (int(cr)-1)/4
+}
+
+func (cr ChallengeRating) String() string {
+	for s, v := range fractionalCr {
+		if v == cr {
+			return s
+		}
+	}
+	return strconv.Itoa(int(cr))
+}
+
+// formatThousands prints n with comma separators, e.g. 10,000.
+func formatThousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseChallengeRating(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  ChallengeRating
+		xp    int
+		bonus int
+		text  string
+		err   bool
+	}{
+		{in: "0", want: 0, xp: 10, bonus: 2, text: "0"},
+		{in: "1/8", want: 0.125, xp: 25, bonus: 2, text: "1/8"},
+		{in: "1/4", want: 0.25, xp: 50, bonus: 2, text: "1/4"},
+		{in: " 1/2 ", want: 0.5, xp: 100, bonus: 2, text: "1/2"},
+		{in: "5", want: 5, xp: 1800, bonus: 3, text: "5"},
+		{in: "17", want: 17, xp: 18000, bonus: 6, text: "17"},
+		{in: "30", want: 30, xp: 155000, bonus: 9, text: "30"},
+		{in: "31", err: true},
+		{in: "", err: true},
+		{in: "—", err: true},
+		{in: "varies", err: true},
+	}
+	for _, tt := range tests {
+		cr, err := ParseChallengeRating(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseChallengeRating(%q) = %v, want an error", tt.in, cr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseChallengeRating(%q): %s", tt.in, err)
+			continue
+		}
+		if cr != tt.want || cr.XP() != tt.xp || cr.ProficiencyBonus() != tt.bonus || cr.String() != tt.text {
+			t.Errorf("ParseChallengeRating(%q) = %v (%d XP, %+d), want %v (%d XP, %+d)",
+				tt.in, cr, cr.XP(), cr.ProficiencyBonus(), tt.text, tt.xp, tt.bonus)
+		}
+	}
+}
+
+func TestChallengeText(t *testing.T) {
+	tests := []struct {
+		cr   string
+		want string
+		has  bool
+	}{
+		{"2", "2 (450 XP)", true},
+		{"1/4", "1/4 (50 XP)", true},
+		{"0", "0 (10 XP)", true},
+		{"21", "21 (33,000 XP)", true},
+		{"—", "—", false},
+		{"varies", "varies", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		m := &Monster{Name: "Test", Cr: tt.cr}
+		m.Parse()
+		if got := m.ChallengeText(); got != tt.want {
+			t.Errorf("ChallengeText for %q = %q, want %q", tt.cr, got, tt.want)
+		}
+		if m.HasChallengeRating != tt.has {
+			t.Errorf("HasChallengeRating for %q = %v, want %v", tt.cr, m.HasChallengeRating, tt.has)
+		}
+		if !tt.has && (m.XP != 0 || m.ProficiencyBonus != 0) {
+			t.Errorf("Unparsed CR %q gave %d XP and proficiency bonus %d", tt.cr, m.XP, m.ProficiencyBonus)
+		}
+	}
+}
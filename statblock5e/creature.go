@@ -0,0 +1,66 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var typeTagsRe = regexp.MustCompile(`^([^(]*?)\s*(?:\(([^)]*)\))?$`)
+
+// ParseCreatureType splits Lion's Den type text such as
+// "humanoid (goblinoid), monster manual" into the base type, its tags and
+// the source book.
+func ParseCreatureType(s string) (string, []string, string) {
+	parts := splitList(s)
+	if len(parts) == 0 {
+		return "", nil, ""
+	}
+	book := strings.Join(parts[1:], ", ")
+	p := typeTagsRe.FindStringSubmatch(parts[0])
+	if p == nil {
+		return parts[0], nil, book
+	}
+	var tags []string
+	for _, tag := range strings.Split(p[2], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return p[1], tags, book
+}
+
+// parseCreatureType fills in the base type, tags, source book and
+// environments.
+func (m *Monster) parseCreatureType() {
+	m.CreatureType, m.Tags, m.SourceBook = ParseCreatureType(m.Type)
+	m.Environments = nil
+	for _, e := range splitList(m.Environment) {
+		m.Environments = append(m.Environments, strings.ToLower(e))
+	}
+}
+
+// FullType returns the type with its tags, e.g. "humanoid (goblinoid)".
+func (m *Monster) FullType() string {
+	if len(m.Tags) == 0 {
+		return m.CreatureType
+	}
+	return m.CreatureType + " (" + strings.Join(m.Tags, ", ") + ")"
+}
+
+// IsType reports whether the base type or one of the tags matches t.
+func (m *Monster) IsType(t string) bool {
+	if strings.EqualFold(m.CreatureType, t) {
+		return true
+	}
+	for _, tag := range m.Tags {
+		if strings.EqualFold(tag, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// InEnvironment reports whether the monster is found in env.
+func (m *Monster) InEnvironment(env string) bool {
+	return isOneOf(strings.ToLower(env), m.Environments)
+}
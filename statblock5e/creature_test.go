@@ -0,0 +1,81 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCreatureType(t *testing.T) {
+	tests := []struct {
+		in   string
+		typ  string
+		tags []string
+		book string
+	}{
+		{"", "", nil, ""},
+		{"beast", "beast", nil, ""},
+		{"humanoid (goblinoid), monster manual", "humanoid", []string{"goblinoid"}, "monster manual"},
+		{"humanoid (any race)", "humanoid", []string{"any race"}, ""},
+		{"fiend (demon, shapechanger), monster manual, page 53", "fiend", []string{"demon", "shapechanger"}, "monster manual, page 53"},
+		{"swarm of Tiny beasts, monster manual", "swarm of Tiny beasts", nil, "monster manual"},
+	}
+	for _, tt := range tests {
+		typ, tags, book := ParseCreatureType(tt.in)
+		if typ != tt.typ || !reflect.DeepEqual(tags, tt.tags) || book != tt.book {
+			t.Errorf("ParseCreatureType(%q) = %q, %q, %q, want %q, %q, %q", tt.in, typ, tags, book, tt.typ, tt.tags, tt.book)
+		}
+	}
+}
+
+func TestCreatureTypeAndEnvironment(t *testing.T) {
+	dir := writeTestData(t, map[string]string{"Creatures.xml": `<compendium>` +
+		`<monster><name>Goblin</name><size>S</size><type>humanoid (goblinoid), monster manual</type>` +
+		`<alignment>neutral evil</alignment><environment>forest, Grassland, underdark</environment></monster>` +
+		`<monster><name>Wolf</name><size>M</size><type>beast</type><alignment>unaligned</alignment></monster>` +
+		`</compendium>`})
+	c, err := LoadCompendium(filepath.Join(dir, "data", "Creatures.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goblin, wolf := c.FindMonster("Goblin"), c.FindMonster("Wolf")
+	if goblin == nil || wolf == nil {
+		t.Fatalf("Monsters %+v", c.Monsters)
+	}
+
+	if s := goblin.Subtitle(); s != "Small humanoid (goblinoid), neutral evil" {
+		t.Errorf("Goblin subtitle %q", s)
+	}
+	if s := wolf.Subtitle(); s != "Medium beast, unaligned" {
+		t.Errorf("Wolf subtitle %q", s)
+	}
+	if goblin.SourceBook != "monster manual" || wolf.SourceBook != "" {
+		t.Errorf("Source books %q and %q", goblin.SourceBook, wolf.SourceBook)
+	}
+
+	for _, typ := range []string{"humanoid", "Goblinoid"} {
+		if !goblin.IsType(typ) {
+			t.Errorf("Goblin is not %q", typ)
+		}
+	}
+	for _, typ := range []string{"beast", "monster manual", "humanoid (goblinoid)", ""} {
+		if goblin.IsType(typ) {
+			t.Errorf("Goblin is %q", typ)
+		}
+	}
+	if !wolf.IsType("Beast") || wolf.IsType("goblinoid") {
+		t.Errorf("Wolf type %q, tags %q", wolf.CreatureType, wolf.Tags)
+	}
+
+	if want := []string{"forest", "grassland", "underdark"}; !reflect.DeepEqual(goblin.Environments, want) {
+		t.Errorf("Goblin environments %q, want %q", goblin.Environments, want)
+	}
+	for _, env := range []string{"Forest", "grassland", "UNDERDARK"} {
+		if !goblin.InEnvironment(env) {
+			t.Errorf("Goblin is not in %q", env)
+		}
+	}
+	if goblin.InEnvironment("arctic") || wolf.InEnvironment("forest") || wolf.Environments != nil {
+		t.Errorf("Wrong environments: goblin %q, wolf %q", goblin.Environments, wolf.Environments)
+	}
+}
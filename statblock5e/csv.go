@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// This file writes monster summaries as CSV for spreadsheets, and reads
+// spreadsheet rows back as simple homebrew monsters.
+
+// CsvColumns is the header of the exported CSV.
+var CsvColumns = []string{"Name", "Source", "Size", "Type", "Alignment", "AC", "HP", "Speed",
+	"Str", "Dex", "Con", "Int", "Wis", "Cha", "CR", "XP"}
+
+// csvAliases maps other common spreadsheet headers to CsvColumns.
+var csvAliases = map[string]string{
+	"armor class": "ac", "hit points": "hp", "challenge": "cr", "challenge rating": "cr",
+	"strength": "str", "dexterity": "dex", "constitution": "con",
+	"intelligence": "int", "wisdom": "wis", "charisma": "cha",
+}
+
+// WriteCsv writes a summary row for each monster.
+func WriteCsv(w io.Writer, monsters []*Monster) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(CsvColumns)
+	if err != nil {
+		return err
+	}
+	for _, m := range monsters {
+		source := filepath.Base(m.Source)
+		source = strings.TrimSuffix(source, filepath.Ext(source))
+		row := []string{m.Name, source, m.SizeName(), m.FullType(), m.Alignment,
+			strconv.Itoa(m.ArmorClass.Value), strconv.Itoa(m.HitPoints.Average), m.Speed}
+		for _, name := range AbilityNames {
+			row = append(row, strconv.Itoa(m.Abilities.Get(name).Score))
+		}
+		if m.HasChallengeRating {
+			row = append(row, m.ChallengeRating.String(), strconv.Itoa(m.XP))
+		} else {
+			row = append(row, "", "")
+		}
+		err = cw.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCsv reads monsters from CSV rows. The first row names the columns,
+// which may come in any order; unknown columns are ignored. The monsters
+// are not parsed yet.
+func ReadCsv(r io.Reader) ([]*Monster, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read CSV header: %s", err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if alias, ok := csvAliases[h]; ok {
+			h = alias
+		}
+		columns[h] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("CSV has no Name column")
+	}
+
+	var monsters []*Monster
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		m := &Monster{
+			Name:      get("name"),
+			Size:      sizeLetter(get("size")),
+			Type:      get("type"),
+			Alignment: get("alignment"),
+			Ac:        get("ac"),
+			Hp:        get("hp"),
+			Speed:     get("speed"),
+			Str:       get("str"),
+			Dex:       get("dex"),
+			Con:       get("con"),
+			Int:       get("int"),
+			Wis:       get("wis"),
+			Cha:       get("cha"),
+			Cr:        get("cr"),
+		}
+		if m.Name == "" {
+			continue
+		}
+		monsters = append(monsters, m)
+	}
+	return monsters, nil
+}
+
+// LoadCsvCompendium loads the monsters in a CSV file.
+func LoadCsvCompendium(path string) (*Compendium, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load compendium from file %q: %s", path, err)
+	}
+	defer f.Close()
+	monsters, err := ReadCsv(f)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse CSV file %q: %s", path, err)
+	}
+
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	c := &Compendium{Name: name, File: path, Monsters: monsters}
+	for _, m := range c.Monsters {
+		m.Source = name
+		for _, err := range m.Parse() {
+			log.Printf("WARNING: Monster %q in %q: %s", m.Name, name, err)
+		}
+	}
+	return c, nil
+}
+
+// WriteCsv writes a summary row for each monster of the encounter.
+func (e *Encounter) WriteCsv(w io.Writer) error {
+	var monsters []*Monster
+	seen := make(map[*Monster]bool)
+	for _, m := range e.Monsters {
+		if m.Monster != nil && !seen[m.Monster] {
+			seen[m.Monster] = true
+			monsters = append(monsters, m.Monster)
+		}
+	}
+	return WriteCsv(w, monsters)
+}
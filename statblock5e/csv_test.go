@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCsvRoundTrip(t *testing.T) {
+	monsters := []*Monster{
+		{Name: "Goblin", Source: "/data/Monster Manual.xml", Size: "S", Type: "humanoid (goblinoid)", Alignment: "neutral evil",
+			Ac: "15 (leather armor, shield)", Hp: "7 (2d6)", Speed: "30 ft.",
+			Str: "8", Dex: "14", Con: "10", Int: "10", Wis: "8", Cha: "8", Cr: "1/4"},
+		{Name: "Adult Red Dragon, Elder", Source: "/data/Homebrew.md", Size: "H", Type: "dragon", Alignment: "chaotic evil",
+			Ac: "19 (natural armor)", Hp: "256 (19d12 + 133)", Speed: "40 ft., climb 40 ft., fly 80 ft.",
+			Str: "27", Dex: "10", Con: "25", Int: "16", Wis: "13", Cha: "21", Cr: "17"},
+		{Name: "Shapeless Thing", Source: "/data/Homebrew.md", Size: "M", Type: "aberration",
+			Ac: "10", Hp: "10", Speed: "0 ft.",
+			Str: "10", Dex: "10", Con: "10", Int: "10", Wis: "10", Cha: "10", Cr: "varies"},
+	}
+	for _, m := range monsters {
+		m.Parse()
+	}
+
+	var b bytes.Buffer
+	if err := WriteCsv(&b, monsters); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		CsvColumns,
+		{"Goblin", "Monster Manual", "Small", "humanoid (goblinoid)", "neutral evil", "15", "7", "30 ft.",
+			"8", "14", "10", "10", "8", "8", "1/4", "50"},
+		{"Adult Red Dragon, Elder", "Homebrew", "Huge", "dragon", "chaotic evil", "19", "256", "40 ft., climb 40 ft., fly 80 ft.",
+			"27", "10", "25", "16", "13", "21", "17", "18000"},
+		{"Shapeless Thing", "Homebrew", "Medium", "aberration", "", "10", "10", "0 ft.",
+			"10", "10", "10", "10", "10", "10", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("WriteCsv wrote\n%q\nwant\n%q", rows, want)
+	}
+
+	read, err := ReadCsv(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(monsters) {
+		t.Fatalf("ReadCsv read %d monsters, want %d", len(read), len(monsters))
+	}
+	for i, m := range read {
+		m.Parse()
+		orig := monsters[i]
+		if m.Name != orig.Name || m.Size != orig.Size || m.Type != orig.Type || m.Alignment != orig.Alignment ||
+			m.ArmorClass.Value != orig.ArmorClass.Value || m.HitPoints.Average != orig.HitPoints.Average ||
+			!reflect.DeepEqual(m.Movement, orig.Movement) || m.Abilities != orig.Abilities ||
+			m.HasChallengeRating != orig.HasChallengeRating || m.ChallengeRating != orig.ChallengeRating {
+			t.Errorf("Round trip of %q gave\n%+v\nwant\n%+v", orig.Name, m, orig)
+		}
+	}
+}
+
+func TestReadCsvHeaders(t *testing.T) {
+	in := "Strength, name ,Armor Class,Hit Points,Challenge Rating,Notes\n" +
+		"18,Ogre Brute,11,59 (7d10 + 21),2,big\n" +
+		"10,,12,5,1,nameless rows are skipped\n"
+	monsters, err := ReadCsv(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(monsters) != 1 {
+		t.Fatalf("ReadCsv read %d monsters, want 1", len(monsters))
+	}
+	m := monsters[0]
+	if m.Name != "Ogre Brute" || m.Str != "18" || m.Ac != "11" || m.Hp != "59 (7d10 + 21)" || m.Cr != "2" {
+		t.Errorf("ReadCsv read %+v", m)
+	}
+
+	if _, err := ReadCsv(strings.NewReader("Size,Type\nM,beast\n")); err == nil {
+		t.Errorf("ReadCsv without a Name column succeeded, want an error")
+	}
+}
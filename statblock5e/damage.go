@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DamageTypes lists the 5e damage types.
+var DamageTypes = []string{
+	"acid", "bludgeoning", "cold", "fire", "force", "lightning", "necrotic",
+	"piercing", "poison", "psychic", "radiant", "slashing", "thunder",
+}
+
+// Conditions lists the 5e conditions.
+var Conditions = []string{
+	"blinded", "charmed", "deafened", "exhaustion", "frightened", "grappled",
+	"incapacitated", "invisible", "paralyzed", "petrified", "poisoned",
+	"prone", "restrained", "stunned", "unconscious",
+}
+
+// DamageEntry is one group of damage types from a resistance, immunity or
+// vulnerability list, along with the qualifier that limits it.
+type DamageEntry struct {
+	Types []string
+	// Nonmagical is set for entries that only apply to nonmagical attacks.
+	Nonmagical bool
+	// Magical is set for entries that only apply to magical attacks.
+	Magical bool
+	// Except lists weapon materials that bypass the entry, e.g. "silvered".
+	Except []string
+	Text   string
+}
+
+// DamageSet is a parsed resistance, immunity or vulnerability list.
+type DamageSet []DamageEntry
+
+// physicalDamageTypes are the types that "from nonmagical attacks" and
+// similar qualifiers apply to.
+var physicalDamageTypes = []string{"bludgeoning", "piercing", "slashing"}
+
+// ParseDamageSet parses text like
+// "necrotic; bludgeoning, piercing, and slashing from nonmagical attacks that
+// aren't silvered". A qualifier only applies to the physical types of its
+// group, so in "cold, fire, bludgeoning, piercing, and slashing from
+// nonmagical attacks" cold and fire get an entry of their own.
+func ParseDamageSet(s string) (DamageSet, error) {
+	var set DamageSet
+	var bad []string
+	for _, group := range strings.Split(s, ";") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+		e := DamageEntry{Text: group}
+		lower := strings.ToLower(group)
+		types, qualifier := lower, ""
+		for _, q := range []string{" from ", " that "} {
+			if i := strings.Index(types, q); i >= 0 {
+				types, qualifier = lower[:i], lower[i:]
+			}
+		}
+		var other DamageEntry
+		for _, word := range strings.FieldsFunc(types, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if !isOneOf(word, DamageTypes) {
+				continue
+			}
+			if qualifier != "" && !isOneOf(word, physicalDamageTypes) {
+				other.Types = append(other.Types, word)
+				continue
+			}
+			e.Types = append(e.Types, word)
+		}
+		if len(e.Types) == 0 {
+			// Without physical types the qualifier is all there is.
+			e.Types, other.Types = other.Types, nil
+		}
+		if len(other.Types) > 0 {
+			other.Text = joinAnd(other.Types)
+			e.Text = joinAnd(e.Types) + group[len(types):]
+			set = append(set, other)
+		}
+		if strings.Contains(qualifier, "nonmagical") {
+			e.Nonmagical = true
+		} else if strings.Contains(qualifier, "magic") {
+			e.Magical = true
+		}
+		for _, material := range []string{"silvered", "adamantine"} {
+			if strings.Contains(qualifier, material) {
+				e.Except = append(e.Except, material)
+			}
+		}
+		if len(e.Types) == 0 {
+			bad = append(bad, group)
+		}
+		set = append(set, e)
+	}
+	if len(bad) > 0 {
+		return set, fmt.Errorf("No damage types found in %q", strings.Join(bad, "; "))
+	}
+	return set, nil
+}
+
+// Has reports whether any entry lists damageType, regardless of qualifiers.
+func (set DamageSet) Has(damageType string) bool {
+	for _, e := range set {
+		if isOneOf(strings.ToLower(damageType), e.Types) {
+			return true
+		}
+	}
+	return false
+}
+
+// Applies reports whether damage of damageType from an attack that is
+// magical or not, and made of the given materials, is affected by the set.
+func (set DamageSet) Applies(damageType string, magical bool, materials ...string) bool {
+	damageType = strings.ToLower(damageType)
+	for _, e := range set {
+		if !isOneOf(damageType, e.Types) {
+			continue
+		}
+		if e.Nonmagical && magical || e.Magical && !magical {
+			continue
+		}
+		bypassed := false
+		for _, m := range materials {
+			if isOneOf(strings.ToLower(m), e.Except) {
+				bypassed = true
+			}
+		}
+		if !bypassed {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseConditions parses a condition immunity list such as
+// "charmed, frightened, poisoned".
+func ParseConditions(s string) ([]string, error) {
+	var conditions, bad []string
+	for _, c := range splitList(s) {
+		c = strings.ToLower(c)
+		if !isOneOf(c, Conditions) {
+			bad = append(bad, c)
+			continue
+		}
+		conditions = append(conditions, c)
+	}
+	if len(bad) > 0 {
+		return conditions, fmt.Errorf("Unknown conditions %q", strings.Join(bad, ", "))
+	}
+	return conditions, nil
+}
+
+func isOneOf(s string, list []string) bool {
+	for _, l := range list {
+		if s == l {
+			return true
+		}
+	}
+	return false
+}
+
+// AdjustDamage applies the monster's immunities, resistances and
+// vulnerabilities to an amount of damage.
+func (m *Monster) AdjustDamage(amount int, damageType string, magical bool, materials ...string) int {
+	if m.DamageImmunities.Applies(damageType, magical, materials...) {
+		return 0
+	}
+	if m.DamageResistances.Applies(damageType, magical, materials...) {
+		amount = amount / 2
+	}
+	if m.DamageVulnerabilities.Applies(damageType, magical, materials...) {
+		amount = amount * 2
+	}
+	return amount
+}
+
+// parseDamage fills in the structured damage and condition fields.
+func (m *Monster) parseDamage() []error {
+	var errs []error
+	var err error
+	m.DamageVulnerabilities, err = ParseDamageSet(m.Vulnerabilities)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Vulnerabilities: %s", err))
+	}
+	m.DamageResistances, err = ParseDamageSet(m.Resistances)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Resistances: %s", err))
+	}
+	m.DamageImmunities, err = ParseDamageSet(m.DamageImmunity)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Damage immunities: %s", err))
+	}
+	m.ConditionImmunities, err = ParseConditions(m.ConditionImmunity)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Condition immunities: %s", err))
+	}
+	return errs
+}
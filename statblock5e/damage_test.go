@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDamageSet(t *testing.T) {
+	tests := []struct {
+		in   string
+		want DamageSet
+		err  bool
+	}{
+		{
+			in:   "fire",
+			want: DamageSet{{Types: []string{"fire"}, Text: "fire"}},
+		},
+		{
+			in: "necrotic; bludgeoning, piercing, and slashing from nonmagical attacks that aren't silvered",
+			want: DamageSet{
+				{Types: []string{"necrotic"}, Text: "necrotic"},
+				{Types: []string{"bludgeoning", "piercing", "slashing"}, Nonmagical: true, Except: []string{"silvered"},
+					Text: "bludgeoning, piercing, and slashing from nonmagical attacks that aren't silvered"},
+			},
+		},
+		{
+			in: "cold, fire, bludgeoning, piercing, and slashing from nonmagical attacks",
+			want: DamageSet{
+				{Types: []string{"cold", "fire"}, Text: "cold and fire"},
+				{Types: []string{"bludgeoning", "piercing", "slashing"}, Nonmagical: true,
+					Text: "bludgeoning, piercing, and slashing from nonmagical attacks"},
+			},
+		},
+		{
+			in: "Piercing and Slashing that aren't adamantine",
+			want: DamageSet{
+				{Types: []string{"piercing", "slashing"}, Except: []string{"adamantine"}, Text: "Piercing and Slashing that aren't adamantine"},
+			},
+		},
+		{
+			in: "bludgeoning, piercing, and slashing from magic weapons",
+			want: DamageSet{
+				{Types: []string{"bludgeoning", "piercing", "slashing"}, Magical: true, Text: "bludgeoning, piercing, and slashing from magic weapons"},
+			},
+		},
+		{
+			in:   "",
+			want: nil,
+		},
+		{
+			in:   "damage from spells",
+			want: DamageSet{{Text: "damage from spells"}},
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		set, err := ParseDamageSet(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseDamageSet(%q) error %v, want error %v", tt.in, err, tt.err)
+		}
+		if !reflect.DeepEqual(set, tt.want) {
+			t.Errorf("ParseDamageSet(%q) =\n%#v\nwant\n%#v", tt.in, set, tt.want)
+		}
+	}
+}
+
+func TestDamageSetApplies(t *testing.T) {
+	set, err := ParseDamageSet("cold, fire, bludgeoning, piercing, and slashing from nonmagical attacks that aren't silvered")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		damageType string
+		magical    bool
+		materials  []string
+		want       bool
+	}{
+		{"cold", false, nil, true},
+		{"fire", true, nil, true},
+		{"slashing", false, nil, true},
+		{"slashing", true, nil, false},
+		{"piercing", false, []string{"silvered"}, false},
+		{"acid", false, nil, false},
+	}
+	for _, tt := range tests {
+		if got := set.Applies(tt.damageType, tt.magical, tt.materials...); got != tt.want {
+			t.Errorf("Applies(%q, %v, %v) = %v, want %v", tt.damageType, tt.magical, tt.materials, got, tt.want)
+		}
+	}
+	custom := foundryDamageTraits(set)
+	if got := custom["value"]; !reflect.DeepEqual(got, []string{"cold", "fire"}) {
+		t.Errorf("Foundry damage types %v, want [cold fire]", got)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dice is a dice expression such as "5d8+5". A flat value like "1" has a
+// Count of zero and only a Bonus.
+type Dice struct {
+	Count int
+	Sides int
+	Bonus int
+}
+
+var diceRe = regexp.MustCompile(`^(?:(\d*)\s*[dD]\s*(\d+))?\s*(?:([+\-–−])\s*(\d+))?$`)
+
+// ParseDice parses expressions like "2d6", "5d8 + 5", "1d4-1" and "7".
+func ParseDice(s string) (Dice, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Dice{}, fmt.Errorf("Empty dice expression")
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return Dice{Bonus: i}, nil
+	}
+	p := diceRe.FindStringSubmatch(s)
+	if p == nil || p[2] == "" {
+		return Dice{}, fmt.Errorf("Invalid dice expression %q", s)
+	}
+	d := Dice{Count: 1}
+	if p[1] != "" {
+		d.Count, _ = strconv.Atoi(p[1])
+	}
+	d.Sides, _ = strconv.Atoi(p[2])
+	if d.Sides == 0 {
+		return Dice{}, fmt.Errorf("Invalid dice expression %q", s)
+	}
+	if p[4] != "" {
+		d.Bonus, _ = strconv.Atoi(p[4])
+		if p[3] != "+" {
+			d.Bonus = -d.Bonus
+		}
+	}
+	return d, nil
+}
+
+// Average returns the average roll, rounded down as the books do.
+func (d Dice) Average() int {
+	return d.Count*(d.Sides+1)/2 + d.Bonus
+}
+
+// Max returns the highest possible roll.
+func (d Dice) Max() int {
+	return d.Count*d.Sides + d.Bonus
+}
+
+// Roll rolls the dice.
+func (d Dice) Roll() int {
+	total := d.Bonus
+	for i := 0; i < d.Count; i++ {
+		total += rand.Intn(d.Sides) + 1
+	}
+	return total
+}
+
+func (d Dice) String() string {
+	if d.Count == 0 {
+		return strconv.Itoa(d.Bonus)
+	}
+	s := fmt.Sprintf("%dd%d", d.Count, d.Sides)
+	if d.Bonus > 0 {
+		s += fmt.Sprintf("+%d", d.Bonus)
+	} else if d.Bonus < 0 {
+		s += fmt.Sprintf("-%d", -d.Bonus)
+	}
+	return s
+}
+
+// HitPoints is the parsed form of a hit point entry like "27 (5d8+5)".
+type HitPoints struct {
+	Average int
+	Dice    Dice
+}
+
+var hpRe = regexp.MustCompile(`^(\d+)\s*(?:\(([^)]*)\))?`)
+
+// ParseHitPoints parses the Lion's Den hp text. If the average is missing it
+// is computed from the dice.
+func ParseHitPoints(s string) (HitPoints, error) {
+	s = strings.TrimSpace(s)
+	p := hpRe.FindStringSubmatch(s)
+	if p == nil {
+		return HitPoints{}, fmt.Errorf("Invalid hit points %q", s)
+	}
+	hp := HitPoints{}
+	hp.Average, _ = strconv.Atoi(p[1])
+	if p[2] == "" {
+		hp.Dice = Dice{Bonus: hp.Average}
+		return hp, nil
+	}
+	d, err := ParseDice(p[2])
+	if err != nil {
+		return hp, fmt.Errorf("Invalid hit dice in %q: %s", s, err)
+	}
+	hp.Dice = d
+	return hp, nil
+}
+
+// Hit point modes for the copies of a monster in an encounter.
+const (
+	HpAverage = "average"
+	HpRoll    = "roll"
+	HpMax     = "max"
+)
+
+// Get returns the starting hit points for a single creature using mode.
+func (hp HitPoints) Get(mode string) (int, error) {
+	switch mode {
+	case "", HpAverage:
+		return hp.Average, nil
+	case HpRoll:
+		if hp.Dice.Count == 0 {
+			return hp.Average, nil
+		}
+		if r := hp.Dice.Roll(); r > 0 {
+			return r, nil
+		}
+		return 1, nil
+	case HpMax:
+		if hp.Dice.Count == 0 {
+			return hp.Average, nil
+		}
+		return hp.Dice.Max(), nil
+	}
+	return 0, fmt.Errorf("Unknown hit point mode %q, must be one of %q, %q or %q", mode, HpAverage, HpRoll, HpMax)
+}
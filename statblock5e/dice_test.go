@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDice(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Dice
+		average int
+		max     int
+		err     bool
+	}{
+		{in: "2d6", want: Dice{2, 6, 0}, average: 7, max: 12},
+		{in: "5d8 + 5", want: Dice{5, 8, 5}, average: 27, max: 45},
+		{in: "1d4-1", want: Dice{1, 4, -1}, average: 1, max: 3},
+		{in: "1d4 − 1", want: Dice{1, 4, -1}, average: 1, max: 3},
+		{in: "d20", want: Dice{1, 20, 0}, average: 10, max: 20},
+		{in: "7", want: Dice{0, 0, 7}, average: 7, max: 7},
+		{in: "", err: true},
+		{in: "2d0", err: true},
+		{in: "lots", err: true},
+	}
+	for _, tt := range tests {
+		d, err := ParseDice(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseDice(%q) = %v, want an error", tt.in, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDice(%q): %s", tt.in, err)
+			continue
+		}
+		if d != tt.want {
+			t.Errorf("ParseDice(%q) = %#v, want %#v", tt.in, d, tt.want)
+		}
+		if d.Average() != tt.average || d.Max() != tt.max {
+			t.Errorf("ParseDice(%q) average %d max %d, want %d and %d", tt.in, d.Average(), d.Max(), tt.average, tt.max)
+		}
+	}
+}
+
+func TestParseHitPoints(t *testing.T) {
+	tests := []struct {
+		in   string
+		want HitPoints
+		err  bool
+	}{
+		{in: "27 (5d8+5)", want: HitPoints{27, Dice{5, 8, 5}}},
+		{in: "7 (2d6)", want: HitPoints{7, Dice{2, 6, 0}}},
+		{in: "1", want: HitPoints{1, Dice{0, 0, 1}}},
+		{in: "10 (lots)", want: HitPoints{Average: 10}, err: true},
+		{in: "varies", err: true},
+	}
+	for _, tt := range tests {
+		hp, err := ParseHitPoints(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseHitPoints(%q) error %v, want error %v", tt.in, err, tt.err)
+		}
+		if hp != tt.want {
+			t.Errorf("ParseHitPoints(%q) = %#v, want %#v", tt.in, hp, tt.want)
+		}
+	}
+}
+
+func TestHitPointsGet(t *testing.T) {
+	hp := HitPoints{27, Dice{5, 8, 5}}
+	for _, tt := range []struct {
+		mode string
+		want int
+	}{
+		{"", 27},
+		{HpAverage, 27},
+		{HpMax, 45},
+	} {
+		if got, err := hp.Get(tt.mode); err != nil || got != tt.want {
+			t.Errorf("Get(%q) = %d, %v, want %d", tt.mode, got, err, tt.want)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if got, _ := hp.Get(HpRoll); got < 10 || got > 45 {
+			t.Fatalf("Get(%q) = %d, want 10 to 45", HpRoll, got)
+		}
+	}
+	if _, err := hp.Get("lots"); err == nil {
+		t.Errorf("Get(%q) succeeded, want an error", "lots")
+	}
+}
+
+func TestRollHitPointsQuantity(t *testing.T) {
+	e, err := NewEncounterFromJson(strings.NewReader(`{"Monsters": [{"Name": "Bat", "Quantity": -1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Fill(func(string) *Monster { return &Monster{Name: "Bat", HitPoints: HitPoints{1, Dice{1, 4, -1}}} })
+	if err := e.RollHitPoints(); err == nil {
+		t.Errorf("RollHitPoints with a negative quantity succeeded, want an error")
+	}
+
+	e.Monsters[0].Quantity = 3
+	if err := e.RollHitPoints(); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Monsters[0].Hp; len(got) != 3 || got[0] != 1 {
+		t.Errorf("RollHitPoints gave %v, want [1 1 1]", got)
+	}
+}
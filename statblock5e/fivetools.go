@@ -0,0 +1,808 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file reads 5etools bestiary files (bestiary-*.json) into the same
+// Compendium and Monster model as the Lion's Den XML. Each monster is
+// rendered into the Lion's Den text fields and then parsed as usual.
+
+// fiveEToolsMonster is a monster as it appears in the JSON, before _copy
+// references are resolved.
+type fiveEToolsMonster map[string]interface{}
+
+// LoadFiveEToolsCompendium loads a single 5etools bestiary file. Monsters
+// that copy from another file must be loaded with
+// LoadFiveEToolsCompendiums instead.
+func LoadFiveEToolsCompendium(path string) (*Compendium, error) {
+	cs, errs := LoadFiveEToolsCompendiums([]string{path})
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return cs[0], nil
+}
+
+// LoadFiveEToolsCompendiums loads several 5etools bestiary files, resolving
+// _copy references across all of them. Files that fail to load are
+// reported in the returned errors and skipped.
+func LoadFiveEToolsCompendiums(paths []string) ([]*Compendium, []error) {
+	var errs []error
+	raw := make(map[string][]fiveEToolsMonster)
+	index := make(map[string]fiveEToolsMonster)
+	for _, path := range paths {
+		monsters, err := readFiveEToolsFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		raw[path] = monsters
+		for _, m := range monsters {
+			index[fiveEToolsKey(jsonString(m["name"]), jsonString(m["source"]))] = m
+		}
+	}
+
+	var cs []*Compendium
+	for _, path := range paths {
+		monsters, ok := raw[path]
+		if !ok {
+			continue
+		}
+		name := filepath.Base(path)
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+		c := &Compendium{Name: name, File: path}
+		for _, fm := range monsters {
+			resolved, err := resolveFiveEToolsCopy(fm, index, 0)
+			if err != nil {
+				log.Printf("WARNING: Skipping monster %q in %q: %s", jsonString(fm["name"]), name, err)
+				continue
+			}
+			m := fiveEToolsToMonster(resolved)
+			m.Source = name
+			for _, err := range m.Parse() {
+				log.Printf("WARNING: Monster %q in %q: %s", m.Name, name, err)
+			}
+			c.Monsters = append(c.Monsters, m)
+		}
+		cs = append(cs, c)
+	}
+	return cs, errs
+}
+
+func readFiveEToolsFile(path string) ([]fiveEToolsMonster, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load compendium from file %q: %s", path, err)
+	}
+	var f struct {
+		Monster []fiveEToolsMonster `json:"monster"`
+	}
+	err = json.Unmarshal(b, &f)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse 5etools file %q: %s", path, err)
+	}
+	if f.Monster == nil {
+		return nil, fmt.Errorf("No monsters found in 5etools file %q", path)
+	}
+	return f.Monster, nil
+}
+
+func fiveEToolsKey(name, source string) string {
+	return strings.ToLower(name + "|" + source)
+}
+
+// fiveEToolsUncopied lists properties that are not inherited through _copy.
+var fiveEToolsUncopied = []string{"_copy", "page", "otherSources", "reprintedAs", "isReprinted", "srd", "basicRules"}
+
+// resolveFiveEToolsCopy returns the monster with its _copy reference, if
+// any, merged in.
+func resolveFiveEToolsCopy(m fiveEToolsMonster, index map[string]fiveEToolsMonster, depth int) (fiveEToolsMonster, error) {
+	cp, ok := m["_copy"].(map[string]interface{})
+	if !ok {
+		return m, nil
+	}
+	if depth > 10 {
+		return nil, fmt.Errorf("_copy chain is too deep")
+	}
+	key := fiveEToolsKey(jsonString(cp["name"]), jsonString(cp["source"]))
+	base, ok := index[key]
+	if !ok {
+		return nil, fmt.Errorf("Could not find %q from %q to copy", jsonString(cp["name"]), jsonString(cp["source"]))
+	}
+	base, err := resolveFiveEToolsCopy(base, index, depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := deepCopyJson(map[string]interface{}(base)).(map[string]interface{})
+	for _, k := range fiveEToolsUncopied {
+		delete(merged, k)
+	}
+	for k, v := range m {
+		if k != "_copy" {
+			merged[k] = deepCopyJson(v)
+		}
+	}
+	if mods, ok := cp["_mod"].(map[string]interface{}); ok {
+		for prop, mod := range mods {
+			ops, ok := mod.([]interface{})
+			if !ok {
+				ops = []interface{}{mod}
+			}
+			for _, op := range ops {
+				err := applyFiveEToolsMod(merged, prop, op)
+				if err != nil {
+					log.Printf("WARNING: Copy of %q: %s", jsonString(m["name"]), err)
+				}
+			}
+		}
+	}
+	return fiveEToolsMonster(merged), nil
+}
+
+func deepCopyJson(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			c[k] = deepCopyJson(v)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(t))
+		for i, v := range t {
+			c[i] = deepCopyJson(v)
+		}
+		return c
+	}
+	return v
+}
+
+// fiveEToolsTextProps are the properties that a "*" replaceTxt mod applies to.
+var fiveEToolsTextProps = []string{"trait", "action", "bonus", "reaction", "legendary", "legendaryHeader", "spellcasting"}
+
+// applyFiveEToolsMod applies a single _mod operation to prop of m.
+func applyFiveEToolsMod(m map[string]interface{}, prop string, op interface{}) error {
+	if s, ok := op.(string); ok && s == "remove" {
+		delete(m, prop)
+		return nil
+	}
+	o, ok := op.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Invalid _mod for %q", prop)
+	}
+	mode := jsonString(o["mode"])
+	if mode == "replaceTxt" {
+		props := []string{prop}
+		if prop == "*" {
+			props = fiveEToolsTextProps
+		}
+		pattern := jsonString(o["replace"])
+		if strings.Contains(jsonString(o["flags"]), "i") {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("Invalid replaceTxt pattern %q: %s", o["replace"], err)
+		}
+		with := regexp.MustCompile(`\$(\d)`).ReplaceAllString(jsonString(o["with"]), "$${$1}")
+		for _, p := range props {
+			if v, ok := m[p]; ok {
+				m[p] = replaceJsonText(v, re, with)
+			}
+		}
+		return nil
+	}
+
+	arr, _ := m[prop].([]interface{})
+	items, ok := o["items"].([]interface{})
+	if !ok && o["items"] != nil {
+		items = []interface{}{o["items"]}
+	}
+	switch mode {
+	case "appendArr":
+		m[prop] = append(arr, items...)
+	case "prependArr":
+		m[prop] = append(append([]interface{}{}, items...), arr...)
+	case "insertArr":
+		i := int(jsonNumber(o["index"]))
+		if i < 0 || i > len(arr) {
+			i = len(arr)
+		}
+		m[prop] = append(append(append([]interface{}{}, arr[:i]...), items...), arr[i:]...)
+	case "replaceArr", "replaceOrAppendArr":
+		i := findJsonItem(arr, o["replace"])
+		switch {
+		case i >= 0:
+			m[prop] = append(append(append([]interface{}{}, arr[:i]...), items...), arr[i+1:]...)
+		case mode == "replaceOrAppendArr":
+			m[prop] = append(arr, items...)
+		default:
+			return fmt.Errorf("Could not find %v in %q to replace", o["replace"], prop)
+		}
+	case "removeArr":
+		names, ok := o["names"].([]interface{})
+		if !ok && o["names"] != nil {
+			names = []interface{}{o["names"]}
+		}
+		names = append(names, items...)
+		for _, n := range names {
+			if i := findJsonItem(arr, n); i >= 0 {
+				arr = append(arr[:i:i], arr[i+1:]...)
+			}
+		}
+		m[prop] = arr
+	default:
+		return fmt.Errorf("Unsupported _mod mode %q for %q", mode, prop)
+	}
+	return nil
+}
+
+// findJsonItem finds an entry by name, by {"index": n}, or by value.
+func findJsonItem(arr []interface{}, key interface{}) int {
+	if k, ok := key.(map[string]interface{}); ok {
+		if i := int(jsonNumber(k["index"])); k["index"] != nil && i >= 0 && i < len(arr) {
+			return i
+		}
+		return -1
+	}
+	name, ok := key.(string)
+	if !ok {
+		return -1
+	}
+	for i, v := range arr {
+		if e, ok := v.(map[string]interface{}); ok && e["name"] == name {
+			return i
+		}
+		if s, ok := v.(string); ok && s == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func replaceJsonText(v interface{}, re *regexp.Regexp, with string) interface{} {
+	switch t := v.(type) {
+	case string:
+		return re.ReplaceAllString(t, with)
+	case []interface{}:
+		for i := range t {
+			t[i] = replaceJsonText(t[i], re, with)
+		}
+	case map[string]interface{}:
+		for k := range t {
+			t[k] = replaceJsonText(t[k], re, with)
+		}
+	}
+	return v
+}
+
+var fiveEToolsTagRe = regexp.MustCompile(`\{@(\w+)\s*([^{}]*)\}`)
+
+var fiveEToolsAttackTypes = map[string]string{
+	"mw":    "Melee Weapon Attack:",
+	"rw":    "Ranged Weapon Attack:",
+	"mw,rw": "Melee or Ranged Weapon Attack:",
+	"ms":    "Melee Spell Attack:",
+	"rs":    "Ranged Spell Attack:",
+	"ms,rs": "Melee or Ranged Spell Attack:",
+}
+
+// renderFiveEToolsTags replaces {@tag ...} markup with plain text, e.g.
+// "{@hit 5}" with "+5" and "{@spell fire bolt}" with "fire bolt".
+func renderFiveEToolsTags(s string) string {
+	for fiveEToolsTagRe.MatchString(s) {
+		s = fiveEToolsTagRe.ReplaceAllStringFunc(s, func(tag string) string {
+			p := fiveEToolsTagRe.FindStringSubmatch(tag)
+			name, text := p[1], strings.TrimSpace(p[2])
+			parts := strings.Split(text, "|")
+			switch name {
+			case "hit":
+				if i, err := strconv.Atoi(parts[0]); err == nil {
+					return formatBonus(i)
+				}
+				return parts[0]
+			case "h":
+				return "Hit: "
+			case "atk":
+				return fiveEToolsAttackTypes[parts[0]]
+			case "dc":
+				return "DC " + parts[0]
+			case "recharge":
+				if parts[0] == "" || parts[0] == "6" {
+					return "(Recharge 6)"
+				}
+				return "(Recharge " + parts[0] + "–6)"
+			case "chance":
+				return parts[0] + " percent"
+			}
+			// Most tags are "{@tag text|source|display text}".
+			if len(parts) >= 3 && parts[2] != "" {
+				return parts[2]
+			}
+			return parts[0]
+		})
+	}
+	return s
+}
+
+// renderFiveEToolsEntries turns 5etools entries into lines of text.
+func renderFiveEToolsEntries(v interface{}) []string {
+	var lines []string
+	for _, e := range jsonList(v) {
+		switch t := e.(type) {
+		case string:
+			lines = append(lines, renderFiveEToolsTags(t))
+		case map[string]interface{}:
+			switch jsonString(t["type"]) {
+			case "list":
+				for _, item := range renderFiveEToolsEntries(t["items"]) {
+					lines = append(lines, "• "+item)
+				}
+			case "table":
+				for _, row := range jsonList(t["rows"]) {
+					var cells []string
+					for _, cell := range jsonList(row) {
+						cells = append(cells, strings.Join(renderFiveEToolsEntries(cell), " "))
+					}
+					lines = append(lines, strings.Join(cells, " | "))
+				}
+			case "inline", "inlineBlock":
+				lines = append(lines, strings.Join(renderFiveEToolsEntries(t["entries"]), ""))
+			default:
+				sub := renderFiveEToolsEntries(t["entries"])
+				if t["entry"] != nil {
+					sub = append(renderFiveEToolsEntries(t["entry"]), sub...)
+				}
+				if name := renderFiveEToolsTags(jsonString(t["name"])); name != "" {
+					if len(sub) > 0 {
+						sub[0] = name + ". " + sub[0]
+					} else {
+						sub = []string{name}
+					}
+				}
+				lines = append(lines, sub...)
+			}
+		}
+	}
+	return lines
+}
+
+var (
+	fiveEToolsHitRe    = regexp.MustCompile(`\{@hit\s+([+-]?\d+)`)
+	fiveEToolsDamageRe = regexp.MustCompile(`\{@damage\s+([^}|]+)`)
+)
+
+// fiveEToolsTrait converts a trait or action entry. Lion's Den attack
+// strings are built from its {@hit} and {@damage} tags.
+func fiveEToolsTrait(v interface{}) Trait {
+	e, _ := v.(map[string]interface{})
+	t := Trait{
+		Name: renderFiveEToolsTags(jsonString(e["name"])),
+		Text: renderFiveEToolsEntries(e["entries"]),
+	}
+	b, _ := json.Marshal(e["entries"])
+	hit := ""
+	if p := fiveEToolsHitRe.FindStringSubmatch(string(b)); p != nil {
+		i, _ := strconv.Atoi(p[1])
+		hit = formatBonus(i)
+	}
+	for _, p := range fiveEToolsDamageRe.FindAllStringSubmatch(string(b), -1) {
+		t.Attack = append(t.Attack, t.Name+"|"+hit+"|"+strings.Replace(p[1], " ", "", -1))
+		hit = ""
+	}
+	return t
+}
+
+func fiveEToolsTraits(v interface{}) []Trait {
+	var traits []Trait
+	for _, e := range jsonList(v) {
+		traits = append(traits, fiveEToolsTrait(e))
+	}
+	return traits
+}
+
+var fiveEToolsAlignments = map[string]string{
+	"L": "lawful", "N": "neutral", "NX": "neutral", "NY": "neutral", "C": "chaotic",
+	"G": "good", "E": "evil", "U": "unaligned", "A": "any alignment",
+}
+
+func fiveEToolsAlignment(v interface{}) string {
+	var codes []string
+	var alternatives []string
+	for _, a := range jsonList(v) {
+		switch t := a.(type) {
+		case string:
+			codes = append(codes, t)
+		case map[string]interface{}:
+			if s := jsonString(t["special"]); s != "" {
+				alternatives = append(alternatives, s)
+			} else {
+				alternatives = append(alternatives, fiveEToolsAlignment(t["alignment"]))
+			}
+		}
+	}
+	if len(alternatives) > 0 {
+		return strings.Join(alternatives, " or ")
+	}
+	if len(codes) >= 4 {
+		// Lists like L, NX, C, E mean any alignment with the remaining axis.
+		for _, c := range codes {
+			if c == "G" || c == "E" {
+				if isOneOf("L", codes) && isOneOf("C", codes) {
+					return "any " + fiveEToolsAlignments[c] + " alignment"
+				}
+			}
+			if c == "L" || c == "C" {
+				if isOneOf("G", codes) && isOneOf("E", codes) {
+					return "any " + fiveEToolsAlignments[c] + " alignment"
+				}
+			}
+		}
+		return "any alignment"
+	}
+	if len(codes) == 2 && codes[0] == "N" && codes[1] == "N" {
+		return "neutral"
+	}
+	var words []string
+	for _, c := range codes {
+		words = append(words, fiveEToolsAlignments[c])
+	}
+	return strings.Join(words, " ")
+}
+
+var fiveEToolsSourceBooks = map[string]string{
+	"MM":   "monster manual",
+	"VGM":  "volo's guide",
+	"MTF":  "mordenkainen's tome of foes",
+	"MPMM": "monsters of the multiverse",
+}
+
+func fiveEToolsType(m fiveEToolsMonster) string {
+	var s string
+	switch t := m["type"].(type) {
+	case string:
+		s = t
+	case map[string]interface{}:
+		switch base := t["type"].(type) {
+		case string:
+			s = base
+		case map[string]interface{}:
+			var choices []string
+			for _, c := range jsonList(base["choose"]) {
+				choices = append(choices, jsonString(c))
+			}
+			s = strings.Join(choices, " or ")
+		}
+		if sw := jsonString(t["swarmSize"]); sw != "" {
+			s = "swarm of " + sizeName(sw) + " " + s + "s"
+		}
+		var tags []string
+		for _, tag := range jsonList(t["tags"]) {
+			switch tt := tag.(type) {
+			case string:
+				tags = append(tags, tt)
+			case map[string]interface{}:
+				tags = append(tags, strings.TrimSpace(jsonString(tt["prefix"])+" "+jsonString(tt["tag"])))
+			}
+		}
+		if len(tags) > 0 {
+			s += " (" + strings.Join(tags, ", ") + ")"
+		}
+	}
+	book := jsonString(m["source"])
+	if b, ok := fiveEToolsSourceBooks[book]; ok {
+		book = b
+	}
+	if book != "" {
+		s += ", " + book
+	}
+	return s
+}
+
+func fiveEToolsAc(v interface{}) string {
+	var s string
+	var conditional []string
+	for i, a := range jsonList(v) {
+		switch t := a.(type) {
+		case float64:
+			if i == 0 {
+				s = jsonString(t)
+			}
+		case map[string]interface{}:
+			ac := jsonString(t["ac"])
+			if cond := jsonString(t["condition"]); cond != "" && i > 0 {
+				conditional = append(conditional, ac+" "+renderFiveEToolsTags(cond))
+				continue
+			}
+			if i > 0 {
+				continue
+			}
+			s = ac
+			var from []string
+			for _, f := range jsonList(t["from"]) {
+				from = append(from, renderFiveEToolsTags(jsonString(f)))
+			}
+			if cond := jsonString(t["condition"]); cond != "" {
+				from = append(from, renderFiveEToolsTags(cond))
+			}
+			conditional = append(from, conditional...)
+		}
+	}
+	if len(conditional) > 0 {
+		s += " (" + strings.Join(conditional, ", ") + ")"
+	}
+	return s
+}
+
+func fiveEToolsHp(v interface{}) string {
+	hp, _ := v.(map[string]interface{})
+	if s := jsonString(hp["special"]); s != "" {
+		return s
+	}
+	s := jsonString(hp["average"])
+	if f := jsonString(hp["formula"]); f != "" {
+		s += " (" + strings.Replace(f, " ", "", -1) + ")"
+	}
+	return s
+}
+
+func fiveEToolsSpeed(v interface{}) string {
+	sp, _ := v.(map[string]interface{})
+	var parts []string
+	for _, mode := range []string{MoveWalk, MoveBurrow, MoveClimb, MoveFly, MoveSwim} {
+		var s string
+		switch t := sp[mode].(type) {
+		case float64:
+			s = jsonString(t) + " ft."
+		case map[string]interface{}:
+			s = jsonString(t["number"]) + " ft."
+			if c := jsonString(t["condition"]); c != "" {
+				s += " " + renderFiveEToolsTags(c)
+			}
+		case bool:
+			continue
+		default:
+			continue
+		}
+		if mode == MoveFly && sp["canHover"] == true && !strings.Contains(s, "hover") {
+			s += " (hover)"
+		}
+		if mode != MoveWalk {
+			s = mode + " " + s
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func fiveEToolsBonuses(v interface{}, order []string) string {
+	b, _ := v.(map[string]interface{})
+	var keys []string
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		oi, oj := len(order), len(order)
+		for n, o := range order {
+			if strings.EqualFold(o, keys[i]) {
+				oi = n
+			}
+			if strings.EqualFold(o, keys[j]) {
+				oj = n
+			}
+		}
+		if oi != oj {
+			return oi < oj
+		}
+		return keys[i] < keys[j]
+	})
+	var parts []string
+	for _, k := range keys {
+		if k == "other" {
+			continue
+		}
+		name := titleCase(k)
+		if a := abilityName(k); a != "" && len(k) == 3 {
+			name = a
+		}
+		parts = append(parts, name+" "+jsonString(b[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fiveEToolsDamage renders a resist, immune or vulnerable list. Groups
+// with a note become separate "; " separated entries.
+func fiveEToolsDamage(v interface{}, key string) string {
+	var plain, groups []string
+	for _, d := range jsonList(v) {
+		switch t := d.(type) {
+		case string:
+			plain = append(plain, t)
+		case map[string]interface{}:
+			if s := jsonString(t["special"]); s != "" {
+				groups = append(groups, s)
+				continue
+			}
+			var types []string
+			for _, dt := range jsonList(t[key]) {
+				types = append(types, jsonString(dt))
+			}
+			s := joinAnd(types)
+			if pre := jsonString(t["preNote"]); pre != "" {
+				s = pre + " " + s
+			}
+			if note := jsonString(t["note"]); note != "" {
+				s += " " + note
+			}
+			groups = append(groups, s)
+		}
+	}
+	if len(plain) > 0 {
+		groups = append([]string{strings.Join(plain, ", ")}, groups...)
+	}
+	return strings.Join(groups, "; ")
+}
+
+func fiveEToolsStrings(v interface{}, key string) string {
+	var l []string
+	for _, s := range jsonList(v) {
+		switch t := s.(type) {
+		case string:
+			l = append(l, renderFiveEToolsTags(t))
+		case map[string]interface{}:
+			for _, n := range jsonList(t[key]) {
+				l = append(l, renderFiveEToolsTags(jsonString(n)))
+			}
+		}
+	}
+	return strings.Join(l, ", ")
+}
+
+// fiveEToolsSpellcasting converts a spellcasting entry into a trait in the
+// layout that ParseSpellcasting reads. It also returns the spell names and
+// slot counts for the Lion's Den spells and slots fields.
+func fiveEToolsSpellcasting(v interface{}) (Trait, []string, []int) {
+	sc, _ := v.(map[string]interface{})
+	t := Trait{Name: renderFiveEToolsTags(jsonString(sc["name"]))}
+	t.Text = renderFiveEToolsEntries(sc["headerEntries"])
+	var all []string
+	var slots []int
+	spellNames := func(v interface{}) string {
+		var names []string
+		for _, s := range jsonList(v) {
+			n := renderFiveEToolsTags(jsonString(s))
+			names = append(names, n)
+			all = append(all, strings.Trim(n, "*"))
+		}
+		return strings.Join(names, ", ")
+	}
+	if will := jsonList(sc["will"]); len(will) > 0 {
+		t.Text = append(t.Text, "At will: "+spellNames(will))
+	}
+	if daily, ok := sc["daily"].(map[string]interface{}); ok {
+		var keys []string
+		for k := range daily {
+			keys = append(keys, k)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+		for _, k := range keys {
+			label := strings.TrimSuffix(k, "e") + "/day"
+			if strings.HasSuffix(k, "e") {
+				label += " each"
+			}
+			t.Text = append(t.Text, label+": "+spellNames(daily[k]))
+		}
+	}
+	if spells, ok := sc["spells"].(map[string]interface{}); ok {
+		for level := 0; level <= 9; level++ {
+			l, ok := spells[strconv.Itoa(level)].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if level == 0 {
+				t.Text = append(t.Text, "Cantrips (at will): "+spellNames(l["spells"]))
+				continue
+			}
+			label := ordinal(level) + " level"
+			if n := int(jsonNumber(l["slots"])); n > 0 {
+				for len(slots) < level {
+					slots = append(slots, 0)
+				}
+				slots[level-1] = n
+				plural := "s"
+				if n == 1 {
+					plural = ""
+				}
+				label += fmt.Sprintf(" (%d slot%s)", n, plural)
+			}
+			t.Text = append(t.Text, label+": "+spellNames(l["spells"]))
+		}
+	}
+	t.Text = append(t.Text, renderFiveEToolsEntries(sc["footerEntries"])...)
+	return t, all, slots
+}
+
+// fiveEToolsToMonster renders a resolved 5etools monster into the Lion's
+// Den fields of a Monster.
+func fiveEToolsToMonster(fm fiveEToolsMonster) *Monster {
+	m := &Monster{
+		Name:              jsonString(fm["name"]),
+		Type:              fiveEToolsType(fm),
+		Alignment:         fiveEToolsAlignment(fm["alignment"]),
+		Ac:                fiveEToolsAc(fm["ac"]),
+		Hp:                fiveEToolsHp(fm["hp"]),
+		Speed:             fiveEToolsSpeed(fm["speed"]),
+		Str:               jsonString(fm["str"]),
+		Dex:               jsonString(fm["dex"]),
+		Con:               jsonString(fm["con"]),
+		Int:               jsonString(fm["int"]),
+		Wis:               jsonString(fm["wis"]),
+		Cha:               jsonString(fm["cha"]),
+		Save:              fiveEToolsBonuses(fm["save"], AbilityNames),
+		Skill:             fiveEToolsBonuses(fm["skill"], nil),
+		Vulnerabilities:   fiveEToolsDamage(fm["vulnerable"], "vulnerable"),
+		Resistances:       fiveEToolsDamage(fm["resist"], "resist"),
+		DamageImmunity:    fiveEToolsDamage(fm["immune"], "immune"),
+		ConditionImmunity: fiveEToolsStrings(fm["conditionImmune"], "conditionImmune"),
+		Senses:            fiveEToolsStrings(fm["senses"], ""),
+		Passive:           jsonString(fm["passive"]),
+		Languages:         fiveEToolsStrings(fm["languages"], ""),
+		Environment:       fiveEToolsStrings(fm["environment"], ""),
+		Traits:            fiveEToolsTraits(fm["trait"]),
+		Actions:           fiveEToolsTraits(fm["action"]),
+		Reactions:         fiveEToolsTraits(fm["reaction"]),
+	}
+	if sizes := jsonList(fm["size"]); len(sizes) > 0 {
+		m.Size = jsonString(sizes[0])
+	}
+	switch cr := fm["cr"].(type) {
+	case string:
+		m.Cr = cr
+	case map[string]interface{}:
+		m.Cr = jsonString(cr["cr"])
+	}
+
+	for _, b := range fiveEToolsTraits(fm["bonus"]) {
+		b.Name += " (Bonus Action)"
+		m.Actions = append(m.Actions, b)
+	}
+
+	var slots []int
+	var spells []string
+	for _, sc := range jsonList(fm["spellcasting"]) {
+		t, s, sl := fiveEToolsSpellcasting(sc)
+		m.Traits = append(m.Traits, t)
+		spells = append(spells, s...)
+		if len(sl) > 0 {
+			slots = sl
+		}
+	}
+	m.Spells = strings.Join(spells, ", ")
+	var slotText []string
+	for _, n := range slots {
+		slotText = append(slotText, strconv.Itoa(n))
+	}
+	m.Slots = strings.Join(slotText, ",")
+
+	if legendary := fiveEToolsTraits(fm["legendary"]); len(legendary) > 0 {
+		header := renderFiveEToolsEntries(fm["legendaryHeader"])
+		if len(header) == 0 {
+			n := int(jsonNumber(fm["legendaryActions"]))
+			if n == 0 {
+				n = 3
+			}
+			header = []string{legendaryIntro(m.Name, n)}
+		}
+		m.Legendary = append([]Trait{{Name: "Legendary Actions", Text: header}}, legendary...)
+	}
+	return m
+}
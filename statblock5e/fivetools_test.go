@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const fiveEToolsGoblin = `{"monster": [{
+	"name": "Goblin",
+	"source": "MM",
+	"page": 166,
+	"size": ["S"],
+	"type": {"type": "humanoid", "tags": ["goblinoid"]},
+	"alignment": ["N", "E"],
+	"ac": [{"ac": 15, "from": ["{@item leather armor|phb}", "{@item shield|phb}"]}],
+	"hp": {"average": 7, "formula": "2d6"},
+	"speed": {"walk": 30},
+	"str": 8, "dex": 14, "con": 10, "int": 10, "wis": 8, "cha": 8,
+	"skill": {"stealth": "+6"},
+	"senses": ["darkvision 60 ft."],
+	"passive": 9,
+	"languages": ["Common", "Goblin"],
+	"cr": "1/4",
+	"trait": [{"name": "Nimble Escape", "entries": ["The goblin can take the {@action Disengage} or {@action Hide} action as a bonus action on each of its turns."]}],
+	"action": [
+		{"name": "Scimitar", "entries": ["{@atk mw} {@hit 4} to hit, reach 5 ft., one target. {@h}5 ({@damage 1d6 + 2}) slashing damage."]},
+		{"name": "Shortbow", "entries": ["{@atk rw} {@hit 4} to hit, range 80/320 ft., one target. {@h}5 ({@damage 1d6 + 2}) piercing damage."]}
+	]
+}]}`
+
+func TestResolveFiveEToolsCopy(t *testing.T) {
+	index := make(map[string]fiveEToolsMonster)
+	var f struct {
+		Monster []fiveEToolsMonster `json:"monster"`
+	}
+	if err := json.Unmarshal([]byte(fiveEToolsGoblin), &f); err != nil {
+		t.Fatal(err)
+	}
+	index[fiveEToolsKey("Goblin", "MM")] = f.Monster[0]
+
+	tests := []struct {
+		name  string
+		copy  string
+		check func(t *testing.T, m fiveEToolsMonster)
+		err   bool
+	}{
+		{
+			name: "inherits and overrides",
+			copy: `{"name": "Goblin Archer", "source": "X", "_copy": {"name": "Goblin", "source": "MM"}, "dex": 16}`,
+			check: func(t *testing.T, m fiveEToolsMonster) {
+				if m["name"] != "Goblin Archer" || jsonNumber(m["dex"]) != 16 || jsonNumber(m["str"]) != 8 {
+					t.Errorf("Copy has name %v, dex %v, str %v", m["name"], m["dex"], m["str"])
+				}
+				if _, ok := m["page"]; ok {
+					t.Errorf("Copy inherited page")
+				}
+			},
+		},
+		{
+			name: "replaceTxt everywhere",
+			copy: `{"name": "Hobgob", "_copy": {"name": "Goblin", "source": "MM", "_mod": {"*": {"mode": "replaceTxt", "replace": "the goblin", "with": "the hobgob", "flags": "i"}}}}`,
+			check: func(t *testing.T, m fiveEToolsMonster) {
+				trait := renderFiveEToolsEntries(m["trait"].([]interface{})[0].(map[string]interface{})["entries"])
+				if want := "the hobgob can take the Disengage or Hide action as a bonus action on each of its turns."; trait[0] != want {
+					t.Errorf("Trait text %q, want %q", trait[0], want)
+				}
+			},
+		},
+		{
+			name: "array modes",
+			copy: `{"name": "Goblin Chief", "_copy": {"name": "Goblin", "source": "MM", "_mod": {
+				"action": [
+					{"mode": "removeArr", "names": "Shortbow"},
+					{"mode": "appendArr", "items": {"name": "Multiattack", "entries": ["Two attacks."]}},
+					{"mode": "replaceArr", "replace": "Scimitar", "items": {"name": "Greataxe", "entries": ["Big."]}}
+				],
+				"trait": "remove"
+			}}}`,
+			check: func(t *testing.T, m fiveEToolsMonster) {
+				var names []string
+				for _, a := range m["action"].([]interface{}) {
+					names = append(names, jsonString(a.(map[string]interface{})["name"]))
+				}
+				if want := []string{"Greataxe", "Multiattack"}; !reflect.DeepEqual(names, want) {
+					t.Errorf("Actions %v, want %v", names, want)
+				}
+				if _, ok := m["trait"]; ok {
+					t.Errorf("Trait was not removed")
+				}
+			},
+		},
+		{
+			name: "copy of a copy",
+			copy: `{"name": "Goblin Boss", "_copy": {"name": "Goblin Archer", "source": "X"}}`,
+			check: func(t *testing.T, m fiveEToolsMonster) {
+				if jsonNumber(m["dex"]) != 16 {
+					t.Errorf("Copy of a copy has dex %v, want 16", m["dex"])
+				}
+			},
+		},
+		{
+			name: "missing source",
+			copy: `{"name": "Nobody", "_copy": {"name": "Orc", "source": "MM"}}`,
+			err:  true,
+		},
+	}
+	archer := fiveEToolsMonster{}
+	json.Unmarshal([]byte(tests[0].copy), &archer)
+	index[fiveEToolsKey("Goblin Archer", "X")] = archer
+
+	for _, tt := range tests {
+		var m fiveEToolsMonster
+		if err := json.Unmarshal([]byte(tt.copy), &m); err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		resolved, err := resolveFiveEToolsCopy(m, index, 0)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: resolved to %v, want an error", tt.name, resolved)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		tt.check(t, resolved)
+	}
+
+	// The base monster is left alone.
+	if len(index[fiveEToolsKey("Goblin", "MM")]["action"].([]interface{})) != 2 {
+		t.Errorf("Resolving copies changed the base monster")
+	}
+}
+
+func TestRenderFiveEToolsTags(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"{@atk mw} {@hit 4} to hit", "Melee Weapon Attack: +4 to hit"},
+		{"{@h}5 ({@damage 1d6 + 2}) slashing damage", "Hit: 5 (1d6 + 2) slashing damage"},
+		{"{@spell fire bolt}", "fire bolt"},
+		{"{@item shield|phb}", "shield"},
+		{"{@dc 13}", "DC 13"},
+	}
+	for _, tt := range tests {
+		if got := renderFiveEToolsTags(tt.in); got != tt.want {
+			t.Errorf("renderFiveEToolsTags(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFiveEToolsCompendiums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statblock5e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mm := filepath.Join(dir, "mm.json")
+	homebrew := filepath.Join(dir, "homebrew.json")
+	ioutil.WriteFile(mm, []byte(fiveEToolsGoblin), 0644)
+	ioutil.WriteFile(homebrew, []byte(`{"monster": [
+		{"name": "Goblin Sniper", "source": "HB", "_copy": {"name": "Goblin", "source": "MM"}, "dex": 18}
+	]}`), 0644)
+
+	cs, errs := LoadFiveEToolsCompendiums([]string{homebrew, mm})
+	if len(errs) > 0 || len(cs) != 2 {
+		t.Fatalf("Loaded %d compendiums with errors %v", len(cs), errs)
+	}
+	m := cs[0].FindMonster("Goblin Sniper")
+	if m == nil {
+		t.Fatalf("Goblin Sniper not found in %q", cs[0].Name)
+	}
+	if m.Abilities.Get("Dex").Score != 18 || m.Ac != "15 (leather armor, shield)" || m.ChallengeText() != "1/4 (50 XP)" {
+		t.Errorf("Goblin Sniper has dex %d, AC %q, CR %q", m.Abilities.Get("Dex").Score, m.Ac, m.ChallengeText())
+	}
+	if len(m.Actions) != 2 || m.Actions[0].Text[0] != "Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 5 (1d6 + 2) slashing damage." {
+		t.Errorf("Goblin Sniper actions %v", m.Actions)
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// This file exports monsters as Foundry VTT actors for the dnd5e system.
+
+var foundrySizes = map[string]string{
+	"T": "tiny", "S": "sm", "M": "med", "L": "lg", "H": "huge", "G": "grg",
+}
+
+var foundrySkills = map[string]string{
+	"Acrobatics": "acr", "Animal Handling": "ani", "Arcana": "arc", "Athletics": "ath",
+	"Deception": "dec", "History": "his", "Insight": "ins", "Intimidation": "itm",
+	"Investigation": "inv", "Medicine": "med", "Nature": "nat", "Perception": "prc",
+	"Performance": "prf", "Persuasion": "per", "Religion": "rel", "Sleight of Hand": "slt",
+	"Stealth": "ste", "Survival": "sur",
+}
+
+var foundryRests = map[string]string{
+	"day": "day", "short rest": "sr", "long rest": "lr", "rest": "sr", "short or long rest": "sr",
+}
+
+// foundryId returns a stable 16 character document id for key, so that
+// re-importing an export updates the same actors. Keys are built from the
+// monster IDs, which are the same for the command line and the server and
+// don't depend on where the data directory is.
+func foundryId(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])[0:16]
+}
+
+func foundryDamageTraits(set DamageSet) map[string]interface{} {
+	var types, custom []string
+	for _, e := range set {
+		if e.Nonmagical || e.Magical || len(e.Except) > 0 {
+			custom = append(custom, e.Text)
+			continue
+		}
+		types = append(types, e.Types...)
+	}
+	if types == nil {
+		types = []string{}
+	}
+	return map[string]interface{}{"value": types, "custom": strings.Join(custom, "; ")}
+}
+
+// FoundryActor converts the monster into a dnd5e "npc" actor.
+func (m *Monster) FoundryActor() map[string]interface{} {
+	abilities := make(map[string]interface{})
+	for _, name := range AbilityNames {
+		a := m.Abilities.Get(name)
+		proficient := 0
+		if a.Save != a.Modifier {
+			proficient = 1
+		}
+		abilities[strings.ToLower(name)] = map[string]interface{}{"value": a.Score, "proficient": proficient}
+	}
+
+	skills := make(map[string]interface{})
+	for name, key := range foundrySkills {
+		mod := m.Abilities.Get(SkillAbilities[name]).Modifier
+		level := 0
+		if m.ProficiencyBonus > 0 {
+			level = (m.Skills[name] - mod) / m.ProficiencyBonus
+		}
+		if level < 0 {
+			level = 0
+		} else if level > 2 {
+			level = 2
+		}
+		skills[key] = map[string]interface{}{"value": level, "ability": strings.ToLower(SkillAbilities[name])}
+	}
+
+	movement := map[string]interface{}{"units": "ft", "hover": m.Hover}
+	for _, mode := range []string{MoveWalk, MoveFly, MoveSwim, MoveClimb, MoveBurrow} {
+		movement[mode] = m.Movement[mode]
+	}
+
+	// Foundry leaves the challenge rating blank for null.
+	var cr, xp interface{}
+	if m.HasChallengeRating {
+		cr, xp = float64(m.ChallengeRating), m.XP
+	}
+
+	spellcasting := ""
+	spellLevel := 0
+	spells := make(map[string]interface{})
+	if sc := m.Spellcasting; sc != nil {
+		spellcasting = strings.ToLower(sc.Ability)
+		spellLevel = sc.Level
+		for i, n := range sc.Slots {
+			spells["spell"+strconv.Itoa(i+1)] = map[string]interface{}{"value": n, "max": n, "override": n}
+		}
+	} else if sc := m.InnateSpellcasting; sc != nil {
+		spellcasting = strings.ToLower(sc.Ability)
+	}
+
+	system := map[string]interface{}{
+		"abilities": abilities,
+		"attributes": map[string]interface{}{
+			"ac": map[string]interface{}{"flat": m.ArmorClass.Value, "calc": "flat"},
+			"hp": map[string]interface{}{
+				"value":   m.HitPoints.Average,
+				"max":     m.HitPoints.Average,
+				"formula": m.HitPoints.Dice.String(),
+			},
+			"movement": movement,
+			"senses": map[string]interface{}{
+				"darkvision":  m.SpecialSenses.Darkvision,
+				"blindsight":  m.SpecialSenses.Blindsight,
+				"tremorsense": m.SpecialSenses.Tremorsense,
+				"truesight":   m.SpecialSenses.Truesight,
+				"units":       "ft",
+				"special":     strings.Join(m.SpecialSenses.Other, ", "),
+			},
+			"spellcasting": spellcasting,
+		},
+		"details": map[string]interface{}{
+			"alignment": m.Alignment,
+			"type": map[string]interface{}{
+				"value":   strings.ToLower(m.CreatureType),
+				"subtype": strings.Join(m.Tags, ", "),
+				"swarm":   "",
+				"custom":  "",
+			},
+			"cr":         cr,
+			"xp":         map[string]interface{}{"value": xp},
+			"source":     m.SourceBook,
+			"spellLevel": spellLevel,
+			"biography":  map[string]interface{}{"value": foundryHtml([]string{m.Description})},
+		},
+		"traits": map[string]interface{}{
+			"size":      foundrySizes[m.Size],
+			"di":        foundryDamageTraits(m.DamageImmunities),
+			"dr":        foundryDamageTraits(m.DamageResistances),
+			"dv":        foundryDamageTraits(m.DamageVulnerabilities),
+			"ci":        map[string]interface{}{"value": append([]string{}, m.ConditionImmunities...), "custom": ""},
+			"languages": map[string]interface{}{"value": []string{}, "custom": m.Languages},
+		},
+		"skills": skills,
+		"spells": spells,
+		"resources": map[string]interface{}{
+			"legact": map[string]interface{}{"value": m.LegendaryActionsPerRound, "max": m.LegendaryActionsPerRound},
+			"lair":   map[string]interface{}{"value": len(m.LairActions) > 0, "initiative": 20},
+		},
+	}
+
+	var items []interface{}
+	for _, group := range []struct {
+		name       string
+		traits     []Trait
+		activation string
+	}{
+		{"trait", m.Traits, ""},
+		{"action", m.Actions, "action"},
+		{"reaction", m.Reactions, "reaction"},
+		{"legendary", m.LegendaryActions, "legendary"},
+		{"lair", m.LairActions, "lair"},
+	} {
+		for _, t := range group.traits {
+			activation := group.activation
+			if activation == "action" && strings.HasSuffix(t.Name, bonusActionSuffix) {
+				activation = "bonus"
+			}
+			items = append(items, t.foundryItem(m.Id+"/"+group.name, activation))
+		}
+	}
+	if items == nil {
+		items = []interface{}{}
+	}
+
+	return map[string]interface{}{
+		"_id":    foundryId(m.Id),
+		"name":   m.Name,
+		"type":   "npc",
+		"img":    "icons/svg/mystery-man.svg",
+		"system": system,
+		"items":  items,
+		"flags":  map[string]interface{}{},
+	}
+}
+
+func foundryHtml(text []string) string {
+	var b strings.Builder
+	for _, t := range text {
+		if t != "" {
+			b.WriteString("<p>" + html.EscapeString(t) + "</p>")
+		}
+	}
+	return b.String()
+}
+
+// foundryActionType guesses the dnd5e action type from the attack text.
+func foundryActionType(text string) string {
+	switch {
+	case strings.Contains(text, "Melee or Ranged Weapon Attack"), strings.Contains(text, "Melee Weapon Attack"):
+		return "mwak"
+	case strings.Contains(text, "Ranged Weapon Attack"):
+		return "rwak"
+	case strings.Contains(text, "Melee Spell Attack"):
+		return "msak"
+	case strings.Contains(text, "Ranged Spell Attack"):
+		return "rsak"
+	case strings.Contains(text, "saving throw"):
+		return "save"
+	}
+	return "other"
+}
+
+// bonusActionSuffix marks the bonus actions the importers add to the
+// actions.
+const bonusActionSuffix = " (Bonus Action)"
+
+// foundryItem converts a trait into an owned item. Traits with an attack
+// roll become weapons, everything else a feat. The item's id is built from
+// group, which must tell apart traits of the same name on one monster.
+func (t Trait) foundryItem(group, activation string) map[string]interface{} {
+	text := strings.Join(t.Text, "\n")
+	system := map[string]interface{}{
+		"description": map[string]interface{}{"value": foundryHtml(t.Text)},
+		"activation":  map[string]interface{}{"type": activation, "cost": 1},
+	}
+	if activation == "legendary" {
+		system["activation"] = map[string]interface{}{"type": activation, "cost": t.LegendaryCost}
+	}
+	if activation == "" {
+		system["activation"] = map[string]interface{}{"type": "", "cost": 0}
+	}
+	if t.RechargeMin > 0 {
+		system["recharge"] = map[string]interface{}{"value": t.RechargeMin, "charged": true}
+	}
+	if t.Uses > 0 {
+		per := foundryRests[t.UsesPer]
+		system["uses"] = map[string]interface{}{"value": t.Uses, "max": strconv.Itoa(t.Uses), "per": per}
+	} else if r, ok := foundryRests[t.RechargeRest]; ok {
+		system["uses"] = map[string]interface{}{"value": 1, "max": "1", "per": r}
+	}
+
+	itemType := "feat"
+	var parts [][]string
+	for _, a := range t.Attacks {
+		parts = append(parts, []string{a.Damage.String(), a.DamageType})
+	}
+	if len(parts) > 0 {
+		system["damage"] = map[string]interface{}{"parts": parts, "versatile": ""}
+		system["actionType"] = foundryActionType(text)
+	}
+	if len(t.Attacks) > 0 && t.Attacks[0].ToHit != nil {
+		// The books give the whole bonus, so the item doesn't add an ability
+		// modifier or proficiency on top of it.
+		itemType = "weapon"
+		system["ability"] = "none"
+		system["proficient"] = false
+		system["attackBonus"] = strconv.Itoa(*t.Attacks[0].ToHit)
+		system["equipped"] = true
+		system["weaponType"] = "natural"
+	}
+	if p := saveDcRe.FindStringSubmatch(text); p != nil {
+		dc, _ := strconv.Atoi(p[1])
+		system["save"] = map[string]interface{}{"ability": strings.ToLower(abilityName(p[2])), "dc": dc, "scaling": "flat"}
+	}
+
+	return map[string]interface{}{
+		"_id":    foundryId(group + "/" + t.Name),
+		"name":   strings.Replace(t.Heading(), bonusActionSuffix, "", 1),
+		"type":   itemType,
+		"img":    "icons/svg/item-bag.svg",
+		"system": system,
+	}
+}
+
+var saveDcRe = regexp.MustCompile(`DC (\d+) (Strength|Dexterity|Constitution|Intelligence|Wisdom|Charisma) saving throw`)
+
+// WriteFoundryActor writes the monster as a single actor JSON document.
+func (m *Monster) WriteFoundryActor(w io.Writer) error {
+	b, err := json.MarshalIndent(m.FoundryActor(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// WriteFoundryPack writes monsters as a Foundry compendium pack, which is
+// one actor JSON document per line. Each monster is written once.
+func WriteFoundryPack(w io.Writer, monsters []*Monster) error {
+	seen := make(map[*Monster]bool)
+	for _, m := range monsters {
+		if m == nil || seen[m] {
+			continue
+		}
+		seen[m] = true
+		b, err := json.Marshal(m.FoundryActor())
+		if err != nil {
+			return fmt.Errorf("Could not export %q: %s", m.Name, err)
+		}
+		_, err = w.Write(append(b, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFoundryPack writes the monsters of the encounter as a Foundry
+// compendium pack.
+func (e *Encounter) WriteFoundryPack(w io.Writer) error {
+	var monsters []*Monster
+	for _, m := range e.Monsters {
+		monsters = append(monsters, m.Monster)
+	}
+	return WriteFoundryPack(w, monsters)
+}
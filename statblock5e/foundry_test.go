@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const foundryDrake = `<monster>
+	<name>Ember Drake</name>
+	<size>L</size>
+	<type>dragon (fire), test book</type>
+	<alignment>chaotic evil</alignment>
+	<ac>17 (natural armor)</ac>
+	<hp>52 (7d10 + 14)</hp>
+	<speed>30 ft., fly 60 ft. (hover)</speed>
+	<str>19</str>
+	<dex>12</dex>
+	<con>15</con>
+	<int>6</int>
+	<wis>11</wis>
+	<cha>8</cha>
+	<save>Dex +3</save>
+	<skill>Perception +4, Stealth +3</skill>
+	<resist>cold; bludgeoning, piercing, and slashing from nonmagical attacks</resist>
+	<immune>fire</immune>
+	<conditionImmune>frightened</conditionImmune>
+	<senses>darkvision 60 ft.</senses>
+	<cr>3</cr>
+	<trait>
+		<name>Legendary Resistance (1/Day)</name>
+		<text>If the drake fails a saving throw, it can choose to succeed instead.</text>
+	</trait>
+	<action>
+		<name>Bite</name>
+		<text>Melee Weapon Attack: +6 to hit, reach 5 ft., one target. Hit: 11 (2d6 + 4) piercing damage.</text>
+		<attack>Bite|+6|2d6+4</attack>
+	</action>
+	<action>
+		<name>Fire Breath (Recharge 5–6)</name>
+		<text>Each creature in a 15-foot cone must make a DC 12 Dexterity saving throw, taking 21 (6d6) fire damage on a failed save.</text>
+		<attack>Fire Breath||6d6</attack>
+	</action>
+	<action>
+		<name>Tail Flick (Bonus Action)</name>
+		<text>The drake moves 10 feet.</text>
+	</action>
+	<legendary>
+		<name>Legendary Actions</name>
+		<text>The drake can take 2 legendary actions.</text>
+	</legendary>
+	<legendary>
+		<name>Bite</name>
+		<text>The drake makes a bite attack.</text>
+	</legendary>
+</monster>`
+
+func foundryTestMonster(t *testing.T) *Monster {
+	t.Helper()
+	m := &Monster{}
+	if err := xml.Unmarshal([]byte(foundryDrake), m); err != nil {
+		t.Fatal(err)
+	}
+	if errs := m.Parse(); len(errs) > 0 {
+		t.Fatalf("Parse: %v", errs)
+	}
+	m.Id = "test/ember-drake"
+	return m
+}
+
+// foundryJson round-trips v through JSON, so that the test compares what
+// Foundry reads.
+func foundryJson(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// foundryPath returns the value at the given keys of nested JSON objects.
+func foundryPath(v interface{}, keys ...string) interface{} {
+	for _, k := range keys {
+		o, _ := v.(map[string]interface{})
+		v = o[k]
+	}
+	return v
+}
+
+func TestFoundryActor(t *testing.T) {
+	m := foundryTestMonster(t)
+	actor := foundryJson(t, m.FoundryActor())
+
+	tests := []struct {
+		path []string
+		want interface{}
+	}{
+		{[]string{"type"}, "npc"},
+		{[]string{"_id"}, foundryId("test/ember-drake")},
+		{[]string{"system", "abilities", "str", "value"}, 19.0},
+		{[]string{"system", "abilities", "dex", "proficient"}, 1.0},
+		{[]string{"system", "abilities", "con", "proficient"}, 0.0},
+		{[]string{"system", "skills", "prc", "value"}, 2.0},
+		{[]string{"system", "skills", "ste", "value"}, 1.0},
+		{[]string{"system", "skills", "ath", "value"}, 0.0},
+		{[]string{"system", "attributes", "ac", "flat"}, 17.0},
+		{[]string{"system", "attributes", "hp", "max"}, 52.0},
+		{[]string{"system", "attributes", "hp", "formula"}, "7d10+14"},
+		{[]string{"system", "attributes", "movement", "walk"}, 30.0},
+		{[]string{"system", "attributes", "movement", "fly"}, 60.0},
+		{[]string{"system", "attributes", "movement", "hover"}, true},
+		{[]string{"system", "attributes", "senses", "darkvision"}, 60.0},
+		{[]string{"system", "details", "type", "value"}, "dragon"},
+		{[]string{"system", "details", "type", "subtype"}, "fire"},
+		{[]string{"system", "details", "cr"}, 3.0},
+		{[]string{"system", "details", "xp", "value"}, 700.0},
+		{[]string{"system", "traits", "size"}, "lg"},
+		{[]string{"system", "traits", "di", "value"}, []interface{}{"fire"}},
+		{[]string{"system", "traits", "dr", "value"}, []interface{}{"cold"}},
+		{[]string{"system", "traits", "dr", "custom"}, "bludgeoning, piercing, and slashing from nonmagical attacks"},
+		{[]string{"system", "traits", "ci", "value"}, []interface{}{"frightened"}},
+		{[]string{"system", "resources", "legact", "max"}, 2.0},
+	}
+	for _, tt := range tests {
+		if got := foundryPath(actor, tt.path...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+	items := map[string]map[string]interface{}{}
+	ids := map[interface{}]bool{}
+	for _, it := range actor["items"].([]interface{}) {
+		item := it.(map[string]interface{})
+		key := item["name"].(string) + "/" + foundryPath(item, "system", "activation", "type").(string)
+		items[key] = item
+		if ids[item["_id"]] {
+			t.Errorf("Item %q has the id of another item", key)
+		}
+		ids[item["_id"]] = true
+	}
+	if len(items) != 5 {
+		t.Fatalf("Items %v, want 5", items)
+	}
+	itemTests := []struct {
+		item string
+		path []string
+		want interface{}
+	}{
+		{"Bite/action", []string{"type"}, "weapon"},
+		{"Bite/action", []string{"system", "attackBonus"}, "6"},
+		{"Bite/action", []string{"system", "ability"}, "none"},
+		{"Bite/action", []string{"system", "actionType"}, "mwak"},
+		{"Bite/action", []string{"system", "damage", "parts"}, []interface{}{[]interface{}{"2d6+4", "piercing"}}},
+		{"Bite/legendary", []string{"type"}, "feat"},
+		{"Bite/legendary", []string{"system", "activation", "cost"}, 1.0},
+		{"Fire Breath (Recharge 5–6)/action", []string{"type"}, "feat"},
+		{"Fire Breath (Recharge 5–6)/action", []string{"system", "recharge", "value"}, 5.0},
+		{"Fire Breath (Recharge 5–6)/action", []string{"system", "actionType"}, "save"},
+		{"Fire Breath (Recharge 5–6)/action", []string{"system", "save", "dc"}, 12.0},
+		{"Fire Breath (Recharge 5–6)/action", []string{"system", "save", "ability"}, "dex"},
+		{"Legendary Resistance (1/Day)/", []string{"system", "uses", "max"}, "1"},
+		{"Legendary Resistance (1/Day)/", []string{"system", "uses", "per"}, "day"},
+		{"Tail Flick/bonus", []string{"type"}, "feat"},
+	}
+	for _, tt := range itemTests {
+		item, ok := items[tt.item]
+		if !ok {
+			t.Errorf("No item %q in %v", tt.item, items)
+			continue
+		}
+		if got := foundryPath(item, tt.path...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %v = %#v, want %#v", tt.item, tt.path, got, tt.want)
+		}
+	}
+
+	// The ids don't depend on where the monster was loaded from.
+	m.Source = "/elsewhere/test.xml"
+	if again := foundryJson(t, m.FoundryActor()); again["_id"] != actor["_id"] {
+		t.Errorf("Actor id changed with the source")
+	}
+}
+
+func TestFoundryNoChallengeRating(t *testing.T) {
+	m := foundryTestMonster(t)
+	m.Cr = "varies"
+	m.Parse()
+	actor := foundryJson(t, m.FoundryActor())
+	if cr, xp := foundryPath(actor, "system", "details", "cr"), foundryPath(actor, "system", "details", "xp", "value"); cr != nil || xp != nil {
+		t.Errorf("CR %v, XP %v, want both null", cr, xp)
+	}
+}
+
+func TestWriteFoundryPack(t *testing.T) {
+	drake := foundryTestMonster(t)
+	e, err := NewEncounterFromJson(strings.NewReader(`{"Name": "Drakes", "Monsters": [
+		{"Name": "Ember Drake", "Quantity": 2}, {"Name": "Ember Drake", "Quantity": 1}, {"Name": "Goblin", "Quantity": 3}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goblin := &Monster{Name: "Goblin", Id: "test/goblin", Cr: "1/4"}
+	goblin.Parse()
+	e.Fill(func(ref string) *Monster {
+		if ref == "Goblin" {
+			return goblin
+		}
+		return drake
+	})
+
+	var b bytes.Buffer
+	if err := e.WriteFoundryPack(&b); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	s := bufio.NewScanner(&b)
+	for s.Scan() {
+		var actor map[string]interface{}
+		if err := json.Unmarshal(s.Bytes(), &actor); err != nil {
+			t.Fatalf("Line %q: %s", s.Text(), err)
+		}
+		names = append(names, actor["name"].(string))
+	}
+	if want := []string{"Ember Drake", "Goblin"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Pack has actors %q, want %q", names, want)
+	}
+}
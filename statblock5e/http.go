@@ -2,20 +2,31 @@ package main
 
 import (
 	"io"
+	"io/ioutil"
 	"log"
 	"net"
 	"encoding/json"
 	"net/http"
-	"path/filepath"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type EncounterServer struct {
 	addr string
 	dir string
-	compendiums map[string]*Compendium
-	monsters map[string]*Monster
 	server *http.ServeMux
+
+	// ReloadInterval is how often the data directory is checked for
+	// changes. Zero disables the check; SIGHUP and /api/reload still work.
+	ReloadInterval time.Duration
+
+	mu sync.RWMutex
+	lib *library
+	reloadMu sync.Mutex
 }
 
 func NewEncounterServer(addr, dir string) (*EncounterServer, error) {
@@ -23,26 +34,10 @@ func NewEncounterServer(addr, dir string) (*EncounterServer, error) {
 		addr = ":80"
 	}
 	es := &EncounterServer{addr: addr, dir: dir}
-	es.monsters = make(map[string]*Monster)
-	es.compendiums = make(map[string]*Compendium)
-
-	files, err := filepath.Glob(dir + "/data/*.xml")
+	_, err := es.Reload()
 	if err != nil {
 		return nil, err
 	}
-	for _, file := range files {
-		c, err := LoadCompendium(file)
-		if err != nil {
-			log.Printf("ERROR: Skipping file %q because it failed loading: %q", file, err)
-			continue
-		}
-		es.compendiums[c.Name] = c
-		for _, m := range c.Monsters {
-			m.Source = c.File
-			es.monsters[m.Name + " (" + c.Name + ")"] = m
-		}
-	}
-
 	return es, nil
 }
 
@@ -53,16 +48,45 @@ func (es *EncounterServer) Serve() error {
 		return err
 	}
 
+	es.routes()
+	go es.watch(es.ReloadInterval)
+	return http.Serve(ln, es.server)
+}
+
+// routes sets up the handlers of es.server.
+func (es *EncounterServer) routes() {
 	es.server = http.NewServeMux()
 	es.server.HandleFunc("/api/encounter/statblock5e", func(w http.ResponseWriter, r *http.Request) {
 		es.handleEncounterStatBlock5e(w,r)
 	})
+	es.server.HandleFunc("/api/encounter/foundry", func(w http.ResponseWriter, r *http.Request) {
+		es.handleEncounterFoundry(w,r)
+	})
 	es.server.HandleFunc("/api/monsters", func(w http.ResponseWriter, r *http.Request) {
 		es.handleMonsterList(w,r)
 	})
+	es.server.HandleFunc("/api/monsters/parse", func(w http.ResponseWriter, r *http.Request) {
+		es.handleMonsterParse(w,r)
+	})
+	es.server.HandleFunc("/api/monsters/", func(w http.ResponseWriter, r *http.Request) {
+		es.handleMonster(w,r)
+	})
+	es.server.HandleFunc("/monsters/", func(w http.ResponseWriter, r *http.Request) {
+		es.handleMonsterPage(w,r)
+	})
+	es.server.HandleFunc("/api/spells", func(w http.ResponseWriter, r *http.Request) {
+		es.handleSpellList(w,r)
+	})
+	es.server.HandleFunc("/api/spells/", func(w http.ResponseWriter, r *http.Request) {
+		es.handleSpell(w,r)
+	})
+	es.server.HandleFunc("/api/items", func(w http.ResponseWriter, r *http.Request) {
+		es.handleItemList(w,r)
+	})
+	es.server.HandleFunc("/api/reload", func(w http.ResponseWriter, r *http.Request) {
+		es.handleReload(w,r)
+	})
 	es.server.Handle("/", http.FileServer(http.Dir(es.dir + "/html")))
-
-	return http.Serve(ln, es.server)
 }
 
 func (es *EncounterServer) handleEncounterStatBlock5e(w http.ResponseWriter, r *http.Request) {
@@ -72,7 +96,11 @@ func (es *EncounterServer) handleEncounterStatBlock5e(w http.ResponseWriter, r *
 		return
 	}
 
-	e.Fill(es.monsters)
+	if s := r.URL.Query().Get("sort"); s != "" {
+		e.Sort = s
+	}
+	e.Fill(es.library().FindMonster)
+	err = e.RollHitPoints()
 	if err != nil {
 		io.WriteString(w, err.Error())
 		return
@@ -84,20 +112,245 @@ func (es *EncounterServer) handleEncounterStatBlock5e(w http.ResponseWriter, r *
 	return
 }
 
+// handleEncounterFoundry returns the monsters of the encounter as a Foundry
+// VTT compendium pack.
+func (es *EncounterServer) handleEncounterFoundry(w http.ResponseWriter, r *http.Request) {
+	e, err := NewEncounterFromJson(r.Body)
+	if err != nil {
+		io.WriteString(w, err.Error())
+		return
+	}
+
+	e.Fill(es.library().FindMonster)
+	name := e.Name
+	if name == "" {
+		name = "encounter"
+	}
+	w.Header().Add(`Content-type`, `application/x-ndjson`)
+	w.Header().Add(`Content-Disposition`, `attachment; filename=` + strconv.Quote(name + ".db"))
+	err = e.WriteFoundryPack(w)
+	if err != nil {
+		io.WriteString(w, "\n\n" + err.Error())
+	}
+}
+
+// handleMonster serves /api/monsters/{id} as JSON, and
+// /api/monsters/{id}/foundry and /api/monsters/{id}/homebrewery. The
+// escaped "Name (Compendium)" key used by older encounters works in place
+// of the ID.
+func (es *EncounterServer) handleMonster(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/monsters/")
+	format := ""
+	for _, f := range []string{"foundry", "homebrewery"} {
+		if strings.HasSuffix(path, "/" + f) {
+			path, format = strings.TrimSuffix(path, "/" + f), f
+		}
+	}
+	m, ok := es.findMonster(w, r, path)
+	if !ok {
+		return
+	}
+	var err error
+	switch format {
+	case "homebrewery":
+		w.Header().Add(`Content-type`, `text/markdown; charset=utf-8`)
+		err = m.WriteHomebrewery(w)
+	case "foundry":
+		w.Header().Add(`Content-type`, `application/json`)
+		err = m.WriteFoundryActor(w)
+	default:
+		var str []byte
+		str, err = json.Marshal(m)
+		if err == nil {
+			w.Header().Add(`Content-type`, `application/json`)
+			w.Write(str)
+		}
+	}
+	if err != nil {
+		io.WriteString(w, "\n\n" + err.Error())
+	}
+}
+
+// handleMonsterPage serves /monsters/{id}, the printable stat block of a
+// single monster.
+func (es *EncounterServer) handleMonsterPage(w http.ResponseWriter, r *http.Request) {
+	m, ok := es.findMonster(w, r, strings.TrimPrefix(r.URL.EscapedPath(), "/monsters/"))
+	if !ok {
+		return
+	}
+	w.Header().Add(`Content-type`, `text/html; charset=utf-8`)
+	err := m.Print(w)
+	if err != nil {
+		io.WriteString(w, "\n\n" + err.Error())
+	}
+}
+
+// findMonster looks up the monster for an escaped ID or key from the URL,
+// writing the error if there is none.
+func (es *EncounterServer) findMonster(w http.ResponseWriter, r *http.Request, ref string) (*Monster, bool) {
+	ref, err := url.PathUnescape(ref)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	m := es.library().FindMonster(ref)
+	if m == nil {
+		http.Error(w, "Unknown monster " + strconv.Quote(ref), http.StatusNotFound)
+		return nil, false
+	}
+	return m, true
+}
+
+// handleMonsterParse parses the plain-text stat block in the request body
+// and returns the monster with the warnings for text it didn't recognize.
+func (es *EncounterServer) handleMonsterParse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		io.WriteString(w, err.Error())
+		return
+	}
+	m, warnings := ParseTextStatBlock(string(b))
+	if warnings == nil {
+		warnings = []string{}
+	}
+	str, err := json.Marshal(struct {
+		Monster *Monster `json:"monster"`
+		Warnings []string `json:"warnings"`
+	}{m, warnings})
+	if err != nil {
+		io.WriteString(w, err.Error())
+		return
+	}
+	w.Header().Add(`Content-type`, `application/json`)
+	w.Write(str)
+}
+
+// handleMonsterList lists the monsters that match the filters, as JSON or,
+// with format=csv, as CSV. POSTing a CSV file with format=csv returns the
+// monsters in it without adding them to the server.
 func (es *EncounterServer) handleMonsterList(w http.ResponseWriter, r *http.Request) {
+	// Read from the URL so that a POSTed body is left for the import.
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		io.WriteString(w, "Unknown format " + strconv.Quote(format))
+		return
+	}
+	if r.Method == http.MethodPost && format == "csv" {
+		es.handleMonsterImport(w,r)
+		return
+	}
 	compendium := strings.ToLower(r.FormValue("compendium"))
-	search := strings.ToLower(r.FormValue("search"))
+	search := r.FormValue("search")
+	movement := strings.ToLower(r.FormValue("movement"))
+	darkvision := r.FormValue("darkvision") != ""
+	creatureType := r.FormValue("type")
+	environment := r.FormValue("environment")
+	resist := r.FormValue("resist")
+	immune := r.FormValue("immune")
+	vulnerable := r.FormValue("vulnerable")
+	minSpeed := 0
+	if v := r.FormValue("minspeed"); v != "" {
+		var err error
+		minSpeed, err = strconv.Atoi(v)
+		if err != nil {
+			io.WriteString(w, err.Error())
+			return
+		}
+		if movement == "" {
+			movement = MoveWalk
+		}
+	}
+	lib := es.library()
+	var scores map[*Monster]float64
+	// A search without any words, such as "trait:", doesn't filter.
+	if len(parseQuery(search)) > 0 {
+		scores = make(map[*Monster]float64)
+		for _, res := range lib.index.Search(search) {
+			scores[res.Monster] = res.Score
+		}
+	}
 	var monsters []*Monster
-	for _, c := range es.compendiums {
+	for _, c := range lib.compendiums {
 		if !strings.Contains(strings.ToLower(c.Name), compendium) {
 			continue
 		}
 		for _, m := range c.Monsters {
-			if strings.Contains(strings.ToLower(m.Name), search) {
-				monsters = append(monsters, m)
+			if _, ok := scores[m]; scores != nil && !ok {
+				continue
+			}
+			if darkvision && !m.SpecialSenses.SeesInDark() {
+				continue
+			}
+			if creatureType != "" && !m.IsType(creatureType) {
+				continue
+			}
+			if environment != "" && !m.InEnvironment(environment) {
+				continue
+			}
+			if resist != "" && !m.DamageResistances.Has(resist) {
+				continue
+			}
+			if immune != "" && !m.DamageImmunities.Has(immune) {
+				continue
+			}
+			if vulnerable != "" && !m.DamageVulnerabilities.Has(vulnerable) {
+				continue
+			}
+			if movement == MoveHover && !m.Hover {
+				continue
+			}
+			if movement != "" {
+				mode := movement
+				if mode == MoveHover {
+					// Hovering is a kind of flying.
+					mode = MoveFly
+				}
+				if speed, ok := m.Movement[mode]; !ok || speed < minSpeed {
+					continue
+				}
 			}
+			monsters = append(monsters, m)
 		}
 	}
+	switch r.FormValue("sort") {
+	case "":
+		if scores != nil {
+			sort.SliceStable(monsters, func(i, j int) bool {
+				if scores[monsters[i]] != scores[monsters[j]] {
+					return scores[monsters[i]] > scores[monsters[j]]
+				}
+				return monsters[i].Name < monsters[j].Name
+			})
+		}
+	case "name":
+		sort.SliceStable(monsters, func(i, j int) bool { return monsters[i].Name < monsters[j].Name })
+	case "cr":
+		sort.SliceStable(monsters, func(i, j int) bool { return monsters[i].ChallengeRating < monsters[j].ChallengeRating })
+	case "passive":
+		sort.SliceStable(monsters, func(i, j int) bool { return monsters[i].PassivePerception > monsters[j].PassivePerception })
+	default:
+		io.WriteString(w, "Unknown sort order " + strconv.Quote(r.FormValue("sort")))
+		return
+	}
+	if format == "csv" {
+		w.Header().Add(`Content-type`, `text/csv; charset=utf-8`)
+		w.Header().Add(`Content-Disposition`, `attachment; filename="monsters.csv"`)
+		err := WriteCsv(w, monsters)
+		if err != nil {
+			io.WriteString(w, "\n\n" + err.Error())
+		}
+		return
+	}
+	writeMonsterList(w, monsters, scores)
+}
+
+// writeMonsterList writes the monsters as JSON. With scores, a search's
+// scores follow as "scores", in the same order as the monsters.
+func writeMonsterList(w http.ResponseWriter, monsters []*Monster, scores map[*Monster]float64) {
 	str, err := json.Marshal(monsters)
 	if err != nil {
 		io.WriteString(w, err.Error())
@@ -106,5 +359,196 @@ func (es *EncounterServer) handleMonsterList(w http.ResponseWriter, r *http.Requ
 	w.Header().Add(`Content-type`, `application/json`)
 	io.WriteString(w, `{"monsters":`)
 	w.Write(str)
+	if scores != nil {
+		list := make([]float64, len(monsters))
+		for i, m := range monsters {
+			list[i] = scores[m]
+		}
+		str, err = json.Marshal(list)
+		if err != nil {
+			io.WriteString(w, err.Error())
+			return
+		}
+		io.WriteString(w, `,"scores":`)
+		w.Write(str)
+	}
+	io.WriteString(w, "}")
+}
+
+func (es *EncounterServer) handleMonsterImport(w http.ResponseWriter, r *http.Request) {
+	monsters, err := ReadCsv(r.Body)
+	if err != nil {
+		io.WriteString(w, err.Error())
+		return
+	}
+	for _, m := range monsters {
+		for _, err := range m.Parse() {
+			log.Printf("WARNING: Monster %q in CSV upload: %s", m.Name, err)
+		}
+	}
+	writeMonsterList(w, monsters, nil)
+}
+
+// handleSpellList lists the spells that match the filters.
+func (es *EncounterServer) handleSpellList(w http.ResponseWriter, r *http.Request) {
+	compendium := strings.ToLower(r.FormValue("compendium"))
+	search := strings.ToLower(r.FormValue("search"))
+	school := r.FormValue("school")
+	class := r.FormValue("class")
+	ritual := r.FormValue("ritual") != ""
+	level := -1
+	if v := r.FormValue("level"); v != "" {
+		var err error
+		level, err = strconv.Atoi(v)
+		if err != nil {
+			io.WriteString(w, err.Error())
+			return
+		}
+	}
+	var spells []*Spell
+	for _, c := range es.library().compendiums {
+		if !strings.Contains(strings.ToLower(c.Name), compendium) {
+			continue
+		}
+		for _, s := range c.Spells {
+			if !strings.Contains(strings.ToLower(s.Name), search) {
+				continue
+			}
+			if level >= 0 && s.Level != level {
+				continue
+			}
+			if school != "" && !strings.EqualFold(s.School, school) && !strings.EqualFold(s.SchoolName(), school) {
+				continue
+			}
+			if class != "" && !s.HasClass(class) {
+				continue
+			}
+			if ritual && !s.IsRitual() {
+				continue
+			}
+			spells = append(spells, s)
+		}
+	}
+	switch r.FormValue("sort") {
+	case "":
+	case "name":
+		sort.SliceStable(spells, func(i, j int) bool { return spells[i].Name < spells[j].Name })
+	case "level":
+		sort.SliceStable(spells, func(i, j int) bool { return spells[i].Level < spells[j].Level })
+	default:
+		io.WriteString(w, "Unknown sort order " + strconv.Quote(r.FormValue("sort")))
+		return
+	}
+	str, err := json.Marshal(spells)
+	if err != nil {
+		io.WriteString(w, err.Error())
+		return
+	}
+	w.Header().Add(`Content-type`, `application/json`)
+	io.WriteString(w, `{"spells":`)
+	w.Write(str)
+	io.WriteString(w, "}")
+}
+
+// handleSpell serves /api/spells/{name}. The name is either the
+// "Name (Compendium)" key or just the spell name, ignoring case.
+func (es *EncounterServer) handleSpell(w http.ResponseWriter, r *http.Request) {
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/api/spells/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s := es.library().FindSpell(name)
+	if s == nil {
+		http.Error(w, "Unknown spell " + strconv.Quote(name), http.StatusNotFound)
+		return
+	}
+	str, err := json.Marshal(s)
+	if err != nil {
+		io.WriteString(w, err.Error())
+		return
+	}
+	w.Header().Add(`Content-type`, `application/json`)
+	w.Write(str)
+}
+
+// handleItemList lists the items that match the filters. The rarity and
+// type filters take a comma separated list of values to accept.
+func (es *EncounterServer) handleItemList(w http.ResponseWriter, r *http.Request) {
+	compendium := strings.ToLower(r.FormValue("compendium"))
+	search := strings.ToLower(r.FormValue("search"))
+	rarities := splitList(strings.ToLower(r.FormValue("rarity")))
+	types := splitList(r.FormValue("type"))
+	magic := r.FormValue("magic")
+	attunement := r.FormValue("attunement")
+	var items []*Item
+	for _, c := range es.library().compendiums {
+		if !strings.Contains(strings.ToLower(c.Name), compendium) {
+			continue
+		}
+		for _, it := range c.Items {
+			if !strings.Contains(strings.ToLower(it.Name), search) {
+				continue
+			}
+			if len(rarities) > 0 && !isOneOf(it.Rarity, rarities) {
+				continue
+			}
+			if len(types) > 0 {
+				ok := false
+				for _, t := range types {
+					ok = ok || it.IsType(t)
+				}
+				if !ok {
+					continue
+				}
+			}
+			if magic != "" && it.IsMagic() != (magic != "0" && magic != "false") {
+				continue
+			}
+			if attunement != "" && it.Attunement != (attunement != "0" && attunement != "false") {
+				continue
+			}
+			items = append(items, it)
+		}
+	}
+	switch r.FormValue("sort") {
+	case "":
+	case "name":
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	case "rarity":
+		sort.SliceStable(items, func(i, j int) bool { return items[i].RarityRank() < items[j].RarityRank() })
+	case "value":
+		sort.SliceStable(items, func(i, j int) bool { return items[i].ValueGp < items[j].ValueGp })
+	default:
+		io.WriteString(w, "Unknown sort order " + strconv.Quote(r.FormValue("sort")))
+		return
+	}
+	str, err := json.Marshal(items)
+	if err != nil {
+		io.WriteString(w, err.Error())
+		return
+	}
+	w.Header().Add(`Content-type`, `application/json`)
+	io.WriteString(w, `{"items":`)
+	w.Write(str)
 	io.WriteString(w, "}")
 }
+
+// handleReload reloads the data directory.
+func (es *EncounterServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	lib, err := es.Reload()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add(`Content-type`, `application/json`)
+	json.NewEncoder(w).Encode(map[string]int{
+		"compendiums": len(lib.compendiums),
+		"monsters": len(lib.monsters),
+		"spells": len(lib.spells),
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Monster IDs are "compendium-slug/monster-slug", e.g.
+// "monster-manual/adult-red-dragon". They stay the same across restarts
+// and reloads, and unlike the "Name (Compendium)" keys they are safe to use
+// in URLs.
+
+// slug lowercases s and replaces each run of other characters than letters
+// and digits with a single dash.
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// setIds gives every monster of the compendium its ID, prefixed with c.Slug,
+// which defaults to the slug of c.Name. Monsters whose names have the same
+// slug are numbered in the order of the file, e.g. "goblin" and "goblin-2",
+// so reordering the file changes which one gets which ID.
+func (c *Compendium) setIds() {
+	if c.Slug == "" {
+		c.Slug = slug(c.Name)
+	}
+	prefix := c.Slug + "/"
+	seen := make(map[string]int)
+	for _, m := range c.Monsters {
+		s := slug(m.Name)
+		seen[s]++
+		if n := seen[s]; n > 1 {
+			s += "-" + strconv.Itoa(n)
+		}
+		m.Id = prefix + s
+	}
+}
+
+// FindMonsterById returns the monster with the given ID, or nil.
+func (c *Compendium) FindMonsterById(id string) *Monster {
+	for _, m := range c.Monsters {
+		if m.Id == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// FindMonster looks a monster up by ID, by its "Name (Compendium)" key, or
+// by the monster part of an ID or the name part of a key if only one
+// compendium has it. The last keeps encounters working when a compendium
+// file is renamed.
+func (lib *library) FindMonster(ref string) *Monster {
+	if m, ok := lib.byId[ref]; ok {
+		return m
+	}
+	if m, ok := lib.monsters[ref]; ok {
+		return m
+	}
+	if m, ok := lib.findUnique(slug(ref[strings.LastIndex(ref, "/")+1:])); ok {
+		return m
+	}
+	if i := strings.LastIndex(ref, " ("); i > 0 && strings.HasSuffix(ref, ")") {
+		m, _ := lib.findUnique(slug(ref[:i]))
+		return m
+	}
+	return nil
+}
+
+// findUnique returns the monster whose ID ends in "/"+s. It reports false if
+// no compendium has one, and returns nil if more than one has.
+func (lib *library) findUnique(s string) (*Monster, bool) {
+	var found *Monster
+	for id, m := range lib.byId {
+		if strings.HasSuffix(id, "/"+s) {
+			if found != nil {
+				return nil, true
+			}
+			found = m
+		}
+	}
+	return found, found != nil
+}
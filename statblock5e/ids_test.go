@@ -0,0 +1,162 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Goblin", "goblin"},
+		{"Adult Red Dragon", "adult-red-dragon"},
+		{"Monster Manual", "monster-manual"},
+		{"Werewolf (Hybrid Form)", "werewolf-hybrid-form"},
+		{"  Yuan-ti -- Pureblood!  ", "yuan-ti-pureblood"},
+		{"Mind Flayer's Élite", "mind-flayer-s-élite"},
+		{"goblins.md", "goblins-md"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := slug(tt.in); got != tt.want {
+			t.Errorf("slug(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSetIds(t *testing.T) {
+	c := &Compendium{Name: "Monster Manual", Monsters: []*Monster{
+		{Name: "Goblin"}, {Name: "Wolf"}, {Name: "goblin"}, {Name: "Goblin!"},
+	}}
+	c.setIds()
+	want := []string{"monster-manual/goblin", "monster-manual/wolf", "monster-manual/goblin-2", "monster-manual/goblin-3"}
+	for i, m := range c.Monsters {
+		if m.Id != want[i] {
+			t.Errorf("Monster %d %q has ID %q, want %q", i, m.Name, m.Id, want[i])
+		}
+	}
+	if m := c.FindMonsterById("monster-manual/goblin-2"); m != c.Monsters[2] {
+		t.Errorf("FindMonsterById found %v, want %v", m, c.Monsters[2])
+	}
+
+	numbered := &Compendium{Name: "monster_manual", Slug: "monster-manual-2", Monsters: []*Monster{{Name: "Goblin"}}}
+	numbered.setIds()
+	if id := numbered.Monsters[0].Id; id != "monster-manual-2/goblin" {
+		t.Errorf("Monster of a numbered compendium has ID %q, want %q", id, "monster-manual-2/goblin")
+	}
+}
+
+func TestFindMonster(t *testing.T) {
+	dir := writeTestData(t, map[string]string{
+		"Bestiary.xml": testCompendium,
+		"Other.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<compendium version="5"><monster><name>Goblin</name><cr>1/4</cr></monster></compendium>`,
+	})
+	lib, err := loadLibrary(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goblin, wolf := lib.byId["bestiary/goblin"], lib.byId["bestiary/wolf"]
+	if goblin == nil || wolf == nil {
+		t.Fatalf("Monsters not loaded: %v", lib.byId)
+	}
+	tests := []struct {
+		ref  string
+		want *Monster
+	}{
+		{"bestiary/wolf", wolf},
+		{"Wolf (Bestiary)", wolf},
+		{"Goblin (Other)", lib.byId["other/goblin"]},
+		// Renamed compendiums.
+		{"monster-manual/wolf", wolf},
+		{"Wolf (Monster Manual)", wolf},
+		{"Wolf", wolf},
+		// More than one compendium has a goblin.
+		{"monster-manual/goblin", nil},
+		{"Goblin (Monster Manual)", nil},
+		{"Orc (Bestiary)", nil},
+	}
+	for _, tt := range tests {
+		if got := lib.FindMonster(tt.ref); got != tt.want {
+			t.Errorf("FindMonster(%q) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestCompendiumSlugCollision(t *testing.T) {
+	dir := writeTestData(t, map[string]string{
+		"Monster Manual.xml": testCompendium,
+		"monster_manual.xml": testCompendium,
+	})
+	lib, err := loadLibrary(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Glob returns "Monster Manual.xml" first.
+	first, second := lib.compendiums["Monster Manual"], lib.compendiums["monster_manual"]
+	if first == nil || second == nil {
+		t.Fatalf("Compendiums not loaded: %v", lib.compendiums)
+	}
+	if first.Slug != "monster-manual" || second.Slug != "monster-manual-2" {
+		t.Errorf("Compendiums have slugs %q and %q, want %q and %q", first.Slug, second.Slug, "monster-manual", "monster-manual-2")
+	}
+	if len(lib.byId) != 4 || lib.byId["monster-manual/goblin"] != first.Monsters[0] || lib.byId["monster-manual-2/goblin"] != second.Monsters[0] {
+		t.Errorf("Monster IDs %v", lib.byId)
+	}
+
+	// A reload keeps the numbering and reuses both files.
+	again, err := loadLibrary(dir, lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.compendiums["Monster Manual"] != first || again.compendiums["monster_manual"] != second {
+		t.Errorf("Reload did not reuse the compendiums")
+	}
+}
+
+func TestCompendiumSlugKeptOnReload(t *testing.T) {
+	dir := writeTestData(t, map[string]string{"monster_manual.xml": testCompendium})
+	lib, err := loadLibrary(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wolf := lib.byId["monster-manual/wolf"]
+	if wolf == nil {
+		t.Fatalf("Wolf not loaded: %v", lib.byId)
+	}
+
+	// "Monster Manual.xml" loads first, but doesn't take the IDs of
+	// monster_manual.xml.
+	added := filepath.Join(dir, "data", "Monster Manual.xml")
+	if err := ioutil.WriteFile(added, []byte(testCompendium), 0644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := loadLibrary(dir, lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := again.FindMonster("monster-manual/wolf"); m != wolf {
+		t.Errorf("monster-manual/wolf is %v, want the wolf of monster_manual.xml", m)
+	}
+	if m := again.FindMonster("monster-manual-2/wolf"); m == nil || m.Source != added {
+		t.Errorf("monster-manual-2/wolf is %v, want the wolf of %q", m, added)
+	}
+
+	// A change to the old file keeps its IDs too.
+	stamp := time.Now().Add(time.Hour)
+	if err := os.Chtimes(wolf.Source, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+	third, err := loadLibrary(dir, again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := third.FindMonster("monster-manual/wolf"); m == nil || m == wolf || m.Source != wolf.Source {
+		t.Errorf("monster-manual/wolf after a change is %v, want a new wolf of %q", m, wolf.Source)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Item is a Lion's Den <item> entry, mundane or magic.
+type Item struct {
+	XMLName   xml.Name       `xml:"item" json:"-"`
+	Source    string         `xml:"-"`
+	Name      string         `xml:"name"`
+	Type      string         `xml:"type,omitempty"`
+	Magic     string         `xml:"magic,omitempty"`
+	Detail    string         `xml:"detail,omitempty"`
+	Weight    string         `xml:"weight,omitempty"`
+	Value     string         `xml:"value,omitempty"`
+	Dmg1      string         `xml:"dmg1,omitempty"`
+	Dmg2      string         `xml:"dmg2,omitempty"`
+	DmgType   string         `xml:"dmgType,omitempty"`
+	Property  string         `xml:"property,omitempty"`
+	Range     string         `xml:"range,omitempty"`
+	Ac        string         `xml:"ac,omitempty"`
+	Strength  string         `xml:"strength,omitempty"`
+	Stealth   string         `xml:"stealth,omitempty"`
+	Text      []string       `xml:"text"`
+	Roll      []string       `xml:"roll,omitempty"`
+	Modifiers []ItemModifier `xml:"modifier,omitempty"`
+
+	TypeName   string `xml:"-"`
+	Rarity     string `xml:"-"`
+	Attunement bool   `xml:"-"`
+	// AttunedBy restricts attunement, e.g. "a wizard".
+	AttunedBy  string   `xml:"-"`
+	Properties []string `xml:"-"`
+	Damage     Dice     `xml:"-"`
+	Versatile  Dice     `xml:"-"`
+	DamageType string   `xml:"-"`
+	WeightLb   float64  `xml:"-"`
+	// ValueGp is the value in gold pieces.
+	ValueGp float64 `xml:"-"`
+
+	Extras []struct {
+		XMLName xml.Name
+		Attrs   []xml.Attr `xml:",any,attr" json:",omitempty"`
+		Content string     `xml:",innerxml"`
+	} `xml:",any"`
+}
+
+// ItemModifier is a Lion's Den modifier such as
+// <modifier category="bonus">melee attacks +1</modifier>.
+type ItemModifier struct {
+	Category string `xml:"category,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+var itemTypes = map[string]string{
+	"LA": "light armor", "MA": "medium armor", "HA": "heavy armor", "S": "shield",
+	"M": "melee weapon", "R": "ranged weapon", "A": "ammunition", "RD": "rod",
+	"ST": "staff", "WD": "wand", "RG": "ring", "P": "potion", "SC": "scroll",
+	"W": "wondrous item", "G": "adventuring gear", "$": "money",
+}
+
+var itemProperties = map[string]string{
+	"A": "ammunition", "F": "finesse", "H": "heavy", "L": "light", "LD": "loading",
+	"R": "reach", "S": "special", "T": "thrown", "2H": "two-handed", "V": "versatile",
+	"M": "martial",
+}
+
+var itemDamageTypes = map[string]string{
+	"A": "acid", "B": "bludgeoning", "C": "cold", "F": "fire", "FC": "force",
+	"L": "lightning", "N": "necrotic", "P": "piercing", "PS": "poison",
+	"PY": "psychic", "R": "radiant", "S": "slashing", "T": "thunder",
+}
+
+// ItemRarities lists the rarities from least to most rare.
+var ItemRarities = []string{"common", "uncommon", "rare", "very rare", "legendary", "artifact"}
+
+var (
+	// raritySlotRe finds the rarity at the start of a detail such as
+	// "very rare (requires attunement)", or after the type, as in
+	// "Weapon (longsword), legendary". Some files label it, as in
+	// "Rarity: Uncommon".
+	raritySlotRe = regexp.MustCompile(`^(?:[^,(.]{1,40}(?:\([^)]*\))?,\s*)?(?:rarity:\s*)?(very rare|uncommon|common|rare|legendary|artifact)\b`)
+	attunementRe = regexp.MustCompile(`(?i)\(?requires attunement(?:\s+by\s+([^)]*))?\)?`)
+	coinsRe      = regexp.MustCompile(`^([\d.,]+)\s*(cp|sp|ep|gp|pp)?$`)
+)
+
+var coinValues = map[string]float64{"cp": 0.01, "sp": 0.1, "ep": 0.5, "gp": 1, "pp": 10}
+
+// Parse fills in the structured fields that are derived from the raw XML
+// text. It returns an error for each value that could not be parsed.
+func (it *Item) Parse() []error {
+	var errs []error
+	it.TypeName = itemTypes[strings.ToUpper(it.Type)]
+	if it.TypeName == "" {
+		it.TypeName = strings.ToLower(it.Type)
+	}
+
+	// The rarity and attunement are in the detail, or in the first line of
+	// the text in older files, e.g. "Wondrous item, rare (requires
+	// attunement)".
+	detail := strings.ToLower(it.Detail)
+	if detail == "" && len(it.Text) > 0 {
+		detail = strings.ToLower(it.Text[0])
+	}
+	// Only the rarity slot counts, so that a description that mentions
+	// "rare" or "legendary" doesn't give the item a rarity.
+	it.Rarity = ""
+	if p := raritySlotRe.FindStringSubmatch(detail); p != nil {
+		it.Rarity = p[1]
+	}
+	it.Attunement, it.AttunedBy = false, ""
+	if p := attunementRe.FindStringSubmatch(detail); p != nil {
+		it.Attunement, it.AttunedBy = true, strings.TrimSpace(p[1])
+	}
+
+	it.Properties = nil
+	for _, p := range strings.Split(it.Property, ",") {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p == "" {
+			continue
+		}
+		name, ok := itemProperties[p]
+		if !ok {
+			name = strings.ToLower(p)
+		}
+		it.Properties = append(it.Properties, name)
+	}
+	it.DamageType = itemDamageTypes[strings.ToUpper(it.DmgType)]
+
+	var err error
+	it.Damage, it.Versatile = Dice{}, Dice{}
+	if it.Dmg1 != "" {
+		if it.Damage, err = ParseDice(it.Dmg1); err != nil {
+			errs = append(errs, fmt.Errorf("Invalid damage %q: %s", it.Dmg1, err))
+		}
+	}
+	if it.Dmg2 != "" {
+		if it.Versatile, err = ParseDice(it.Dmg2); err != nil {
+			errs = append(errs, fmt.Errorf("Invalid versatile damage %q: %s", it.Dmg2, err))
+		}
+	}
+
+	it.WeightLb = 0
+	if w := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(it.Weight), "lb.")); w != "" {
+		if it.WeightLb, err = strconv.ParseFloat(w, 64); err != nil {
+			errs = append(errs, fmt.Errorf("Invalid weight %q", it.Weight))
+		}
+	}
+	it.ValueGp = 0
+	if v := strings.ToLower(strings.TrimSpace(it.Value)); v != "" {
+		p := coinsRe.FindStringSubmatch(v)
+		if p == nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q", it.Value))
+		} else {
+			it.ValueGp, _ = strconv.ParseFloat(strings.Replace(p[1], ",", "", -1), 64)
+			if p[2] != "" {
+				it.ValueGp *= coinValues[p[2]]
+			}
+		}
+	}
+	return errs
+}
+
+// IsMagic reports whether the item is a magic item.
+func (it *Item) IsMagic() bool {
+	return it.Magic == "1" || strings.EqualFold(it.Magic, "YES") || it.Rarity != ""
+}
+
+// RarityRank orders items by rarity; items without one come first.
+func (it *Item) RarityRank() int {
+	for i, r := range ItemRarities {
+		if r == it.Rarity {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// IsType reports whether the item's type code or name matches t.
+func (it *Item) IsType(t string) bool {
+	return strings.EqualFold(it.Type, t) || strings.EqualFold(it.TypeName, t)
+}
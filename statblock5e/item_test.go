@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestItemRarity(t *testing.T) {
+	tests := []struct {
+		detail     string
+		text       string
+		rarity     string
+		attunement bool
+		attunedBy  string
+	}{
+		{detail: "uncommon", rarity: "uncommon"},
+		{detail: "Rarity: Uncommon", rarity: "uncommon"},
+		{text: "Rarity: very rare (requires attunement)", rarity: "very rare", attunement: true},
+		{detail: "very rare (requires attunement)", rarity: "very rare", attunement: true},
+		{detail: "Wondrous item, rare", rarity: "rare"},
+		{text: "Staff, very rare (requires attunement by a sorcerer, warlock, or wizard)",
+			rarity: "very rare", attunement: true, attunedBy: "a sorcerer, warlock, or wizard"},
+		{text: "Weapon (any sword), legendary (requires attunement)", rarity: "legendary", attunement: true},
+		{text: "Wondrous item, rarity varies", rarity: ""},
+		{text: "This rare tome is bound in dragon hide.", rarity: ""},
+		{text: "A common sight in taverns, this mug never spills.", rarity: ""},
+		{detail: "artifact (requires attunement)", text: "Once a legendary blade.", rarity: "artifact", attunement: true},
+	}
+	for _, tt := range tests {
+		it := Item{Name: "Test", Detail: tt.detail}
+		if tt.text != "" {
+			it.Text = []string{tt.text}
+		}
+		it.Parse()
+		if it.Rarity != tt.rarity || it.Attunement != tt.attunement || it.AttunedBy != tt.attunedBy {
+			t.Errorf("Parse(%q, %q) = %q, %v, %q, want %q, %v, %q", tt.detail, tt.text,
+				it.Rarity, it.Attunement, it.AttunedBy, tt.rarity, tt.attunement, tt.attunedBy)
+		}
+	}
+}
+
+func TestItemParse(t *testing.T) {
+	it := Item{Name: "Longsword", Type: "M", Weight: "3 lb.", Value: "15 gp",
+		Dmg1: "1d8", Dmg2: "1d10", DmgType: "S", Property: "M, V"}
+	if errs := it.Parse(); len(errs) > 0 {
+		t.Fatalf("Parse: %v", errs)
+	}
+	if it.TypeName != "melee weapon" || it.WeightLb != 3 || it.ValueGp != 15 || it.DamageType != "slashing" ||
+		it.Damage.String() != "1d8" || it.Versatile.String() != "1d10" || len(it.Properties) != 2 || it.IsMagic() {
+		t.Errorf("Parse gave %+v", it)
+	}
+
+	coins := Item{Name: "Candle", Value: "1 cp"}
+	coins.Parse()
+	if coins.ValueGp != 0.01 {
+		t.Errorf("Value %q = %v gp, want 0.01", coins.Value, coins.ValueGp)
+	}
+
+	bad := Item{Name: "Broken", Weight: "heavy", Value: "a lot", Dmg1: "lots"}
+	if errs := bad.Parse(); len(errs) != 3 {
+		t.Errorf("Parse of %+v gave errors %v, want 3", bad, errs)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var legendaryCountRe = regexp.MustCompile(`(?i)can take (\d+) legendary actions`)
+
+// splitLegendary sorts the Lion's Den legendary entries into legendary
+// actions, lair actions and regional effects. Lion's Den files mark each
+// section with a header entry ("Lair Actions") or a name prefix
+// ("Lair Actions: Magma"); entries after a header belong to its section.
+func (m *Monster) splitLegendary() {
+	m.LegendaryIntro, m.LegendaryActions = nil, nil
+	m.LairIntro, m.LairActions = nil, nil
+	m.RegionalIntro, m.RegionalEffects = nil, nil
+	m.LegendaryActionsPerRound = 0
+
+	intro, section := &m.LegendaryIntro, &m.LegendaryActions
+	for _, t := range m.Legendary {
+		name := strings.ToLower(t.DisplayName)
+		switch {
+		case strings.HasPrefix(name, "legendary action"):
+			intro, section = &m.LegendaryIntro, &m.LegendaryActions
+		case strings.HasPrefix(name, "lair action"):
+			intro, section = &m.LairIntro, &m.LairActions
+		case strings.HasPrefix(name, "regional effect"):
+			intro, section = &m.RegionalIntro, &m.RegionalEffects
+		default:
+			*section = append(*section, t)
+			continue
+		}
+		// A header is either a section intro or a prefixed entry.
+		if i := strings.Index(t.DisplayName, ":"); i >= 0 {
+			t.DisplayName = strings.TrimSpace(t.DisplayName[i+1:])
+			*section = append(*section, t)
+			continue
+		}
+		*intro = append(*intro, t.Text...)
+	}
+
+	for i := range m.LegendaryActions {
+		if m.LegendaryActions[i].LegendaryCost == 0 {
+			m.LegendaryActions[i].LegendaryCost = 1
+		}
+	}
+	if len(m.LegendaryActions) > 0 {
+		m.LegendaryActionsPerRound = 3
+		for _, text := range m.LegendaryIntro {
+			if p := legendaryCountRe.FindStringSubmatch(text); p != nil {
+				m.LegendaryActionsPerRound, _ = strconv.Atoi(p[1])
+			}
+		}
+	}
+}
+
+// legendaryIntro returns the standard legendary actions intro for formats
+// that only give the number of actions.
+func legendaryIntro(name string, n int) string {
+	name = strings.ToLower(name)
+	return fmt.Sprintf("The %s can take %d legendary actions, choosing from the options below. Only one legendary action option can be used at a time and only at the end of another creature's turn. The %s regains spent legendary actions at the start of its turn.", name, n, name)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func displayNames(list []Trait) []string {
+	var names []string
+	for _, t := range list {
+		names = append(names, t.DisplayName)
+	}
+	return names
+}
+
+func TestSplitLegendary(t *testing.T) {
+	m := &Monster{Name: "Dragon", Legendary: []Trait{
+		{Name: "Legendary Actions", Text: []string{"The dragon can take 2 legendary actions, choosing from the options below."}},
+		{Name: "Detect", Text: []string{"The dragon makes a Wisdom (Perception) check."}},
+		{Name: "Wing Attack (Costs 2 Actions)", Text: []string{"The dragon beats its wings."}},
+		{Name: "Lair Actions", Text: []string{"On initiative count 20, the dragon takes a lair action."}},
+		{Name: "Magma Eruption", Text: []string{"Magma erupts from a point on the ground."}},
+		{Name: "Tremor", Text: []string{"A tremor shakes the lair."}},
+		{Name: "Regional Effects: Smoke", Text: []string{"Smoke fills the air within 6 miles of the lair."}},
+	}}
+	m.Parse()
+
+	if got, want := displayNames(m.LegendaryActions), []string{"Detect", "Wing Attack"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Legendary actions %q, want %q", got, want)
+	}
+	if costs := []int{m.LegendaryActions[0].LegendaryCost, m.LegendaryActions[1].LegendaryCost}; costs[0] != 1 || costs[1] != 2 {
+		t.Errorf("Legendary costs %v, want [1 2]", costs)
+	}
+	if len(m.LegendaryIntro) != 1 || m.LegendaryActionsPerRound != 2 {
+		t.Errorf("Legendary intro %q, %d per round, want 2", m.LegendaryIntro, m.LegendaryActionsPerRound)
+	}
+	if got, want := displayNames(m.LairActions), []string{"Magma Eruption", "Tremor"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lair actions %q, want %q", got, want)
+	}
+	if want := []string{"On initiative count 20, the dragon takes a lair action."}; !reflect.DeepEqual(m.LairIntro, want) {
+		t.Errorf("Lair intro %q, want %q", m.LairIntro, want)
+	}
+	if got, want := displayNames(m.RegionalEffects), []string{"Smoke"}; !reflect.DeepEqual(got, want) || len(m.RegionalIntro) != 0 {
+		t.Errorf("Regional effects %q, intro %q, want %q and no intro", got, m.RegionalIntro, want)
+	}
+
+	// Parsing again gives the same sections.
+	m.Parse()
+	if len(m.LegendaryActions) != 2 || len(m.LairActions) != 2 || len(m.RegionalEffects) != 1 {
+		t.Errorf("Parsing twice gave %d, %d and %d entries", len(m.LegendaryActions), len(m.LairActions), len(m.RegionalEffects))
+	}
+}
+
+func TestSplitLegendaryDefaults(t *testing.T) {
+	m := &Monster{Name: "Lich", Legendary: []Trait{
+		{Name: "Cantrip", Text: []string{"The lich casts a cantrip."}},
+		{Name: "Lair Actions: Tether", Text: []string{"The lich tethers itself to a creature."}},
+	}}
+	m.Parse()
+	if got, want := displayNames(m.LegendaryActions), []string{"Cantrip"}; !reflect.DeepEqual(got, want) || m.LegendaryActionsPerRound != 3 {
+		t.Errorf("Legendary actions %q, %d per round, want %q and 3", got, m.LegendaryActionsPerRound, want)
+	}
+	if got, want := displayNames(m.LairActions), []string{"Tether"}; !reflect.DeepEqual(got, want) || len(m.LairIntro) != 0 {
+		t.Errorf("Lair actions %q, intro %q, want %q and no intro", got, m.LairIntro, want)
+	}
+
+	m = &Monster{Name: "Ogre"}
+	m.Parse()
+	if m.LegendaryActionsPerRound != 0 || m.LegendaryActions != nil {
+		t.Errorf("Ogre has %d legendary actions per round", m.LegendaryActionsPerRound)
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// library is the data directory as loaded at one point in time. It is not
+// modified once built; a reload builds a new one and swaps it in, so a
+// request always sees complete maps.
+type library struct {
+	compendiums map[string]*Compendium
+	monsters    map[string]*Monster
+	byId        map[string]*Monster
+	slugs       map[string]*Compendium
+	spells      map[string]*Spell
+	index       *searchIndex
+
+	// files holds the size and modification time of each data file, and
+	// loaded its compendium (nil if it failed loading), so that a reload
+	// only parses the files that changed.
+	files      map[string]fileStamp
+	loaded     map[string]*Compendium
+	fiveETools []string
+
+	// kept holds, while loading, the compendiums of the previous library
+	// by file, for the files that are still there. Their names and ID
+	// prefixes stay reserved for them.
+	kept map[string]*Compendium
+}
+
+type fileStamp struct {
+	size    int64
+	modTime int64
+}
+
+// dataPatterns are the files loaded from the data directory.
+var dataPatterns = []string{"/data/*.xml", "/data/*.json", "/data/*.md", "/data/*.csv"}
+
+// dataFiles returns the stamp of every data file under dir.
+func dataFiles(dir string) (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+	for _, pattern := range dataPatterns {
+		files, err := filepath.Glob(dir + pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			fi, err := os.Stat(file)
+			if err != nil {
+				// Removed since the glob; the next check picks it up.
+				continue
+			}
+			stamps[file] = fileStamp{fi.Size(), fi.ModTime().UnixNano()}
+		}
+	}
+	return stamps, nil
+}
+
+// loadLibrary loads the data directory. Files that haven't changed since
+// prev was loaded are taken from prev instead of being parsed again.
+func loadLibrary(dir string, prev *library) (*library, error) {
+	stamps, err := dataFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	lib := &library{
+		compendiums: make(map[string]*Compendium),
+		monsters:    make(map[string]*Monster),
+		byId:        make(map[string]*Monster),
+		slugs:       make(map[string]*Compendium),
+		spells:      make(map[string]*Spell),
+		files:       stamps,
+		loaded:      make(map[string]*Compendium),
+		kept:        make(map[string]*Compendium),
+	}
+	if prev != nil {
+		for file, c := range prev.loaded {
+			if _, ok := stamps[file]; ok && c != nil {
+				lib.kept[file] = c
+			}
+		}
+	}
+	unchanged := func(file string) bool {
+		if prev == nil {
+			return false
+		}
+		_, ok := prev.loaded[file]
+		return ok && prev.files[file] == stamps[file]
+	}
+	// reusable reports whether prev's compendium of file can be used as is:
+	// the file is unchanged and the compendium would get the same name and
+	// monster IDs.
+	reusable := func(file string) bool {
+		if !unchanged(file) {
+			return false
+		}
+		c := prev.loaded[file]
+		return c == nil || c.Name == lib.compendiumName(file) && c.Slug == lib.compendiumSlug(file, c.Name)
+	}
+	load := func(file string, loader func(string) (*Compendium, error)) {
+		var c *Compendium
+		if reusable(file) {
+			c = prev.loaded[file]
+		} else {
+			var err error
+			c, err = loader(file)
+			if err != nil {
+				log.Printf("ERROR: Skipping file %q because it failed loading: %q", file, err)
+				c = nil
+			} else {
+				c.Name = lib.compendiumName(file)
+				c.Slug = lib.compendiumSlug(file, c.Name)
+				c.prepare()
+			}
+		}
+		lib.loaded[file] = c
+		if c != nil {
+			lib.addCompendium(c)
+		}
+	}
+
+	files, _ := filepath.Glob(dir + "/data/*.xml")
+	for _, file := range files {
+		load(file, LoadCompendium)
+	}
+
+	// 5etools files are loaded together so that _copy can refer to
+	// monsters in other files, and are all loaded again if one changed.
+	files, _ = filepath.Glob(dir + "/data/*.json")
+	var fiveETools []string
+	for _, file := range files {
+		if unchanged(file) {
+			i := sort.SearchStrings(prev.fiveETools, file)
+			if i < len(prev.fiveETools) && prev.fiveETools[i] == file {
+				fiveETools = append(fiveETools, file)
+				continue
+			}
+		} else if !IsSrdFile(file) {
+			fiveETools = append(fiveETools, file)
+			continue
+		}
+		load(file, LoadSrdCompendium)
+	}
+	sort.Strings(fiveETools)
+	lib.fiveETools = fiveETools
+	reuse := prev != nil && len(fiveETools) == len(prev.fiveETools)
+	for i, file := range fiveETools {
+		reuse = reuse && prev.fiveETools[i] == file && reusable(file)
+	}
+	if reuse {
+		for _, file := range fiveETools {
+			lib.loaded[file] = prev.loaded[file]
+			if c := prev.loaded[file]; c != nil {
+				lib.addCompendium(c)
+			}
+		}
+	} else {
+		cs, errs := LoadFiveEToolsCompendiums(fiveETools)
+		for _, err := range errs {
+			log.Printf("ERROR: Skipping file because it failed loading: %q", err)
+		}
+		for _, file := range fiveETools {
+			lib.loaded[file] = nil
+		}
+		for _, c := range cs {
+			c.Name = lib.compendiumName(c.File)
+			c.Slug = lib.compendiumSlug(c.File, c.Name)
+			c.prepare()
+			lib.loaded[c.File] = c
+			lib.addCompendium(c)
+		}
+	}
+
+	for _, pattern := range []string{"/data/*.md", "/data/*.csv"} {
+		files, _ = filepath.Glob(dir + pattern)
+		for _, file := range files {
+			load(file, LoadCompendium)
+		}
+	}
+	lib.index = newSearchIndex(lib.allMonsters())
+	lib.kept = nil
+	return lib, nil
+}
+
+// allMonsters returns the monsters of every compendium, ordered by
+// compendium name.
+func (lib *library) allMonsters() []*Monster {
+	var names []string
+	for name := range lib.compendiums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var monsters []*Monster
+	for _, name := range names {
+		monsters = append(monsters, lib.compendiums[name].Monsters...)
+	}
+	return monsters
+}
+
+// FindSpell looks a spell up by its "Name (Compendium)" key, or else by
+// name in the compendiums in alphabetical order.
+func (lib *library) FindSpell(name string) *Spell {
+	if s, ok := lib.spells[name]; ok {
+		return s
+	}
+	var names []string
+	for n := range lib.compendiums {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if s := lib.compendiums[n].FindSpell(name); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// prepare sets what the library needs on a newly loaded compendium. It
+// runs once per load of a file: compendiums reused by a reload are shared
+// with requests on the previous library and must not be written to again.
+func (c *Compendium) prepare() {
+	c.setIds()
+	for _, m := range c.Monsters {
+		m.Source = c.File
+		m.Compendium = c.Name
+	}
+	for _, s := range c.Spells {
+		s.Source = c.File
+	}
+	for _, it := range c.Items {
+		it.Source = c.File
+	}
+}
+
+// compendiumName returns the name of the compendium of file in lib: the name
+// it had before the reload, or else the file name without its extension, or
+// with it when another file, such as goblins.xml next to goblins.json,
+// already has that name. A file that is added later never renames one that
+// is already loaded.
+func (lib *library) compendiumName(file string) string {
+	if c := lib.kept[file]; c != nil {
+		return c.Name
+	}
+	base := filepath.Base(file)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	if _, ok := lib.compendiums[name]; ok {
+		return base
+	}
+	for f, c := range lib.kept {
+		if f != file && c.Name == name {
+			return base
+		}
+	}
+	return name
+}
+
+// compendiumSlug returns the ID prefix of the compendium of file, called
+// name, in lib: the prefix it had before the reload, or else the slug of
+// name, numbered when another compendium, such as "Monster Manual" next to
+// "monster_manual", already has it. Like names, a file that is added later
+// never takes the IDs of monsters that are already loaded; at startup the
+// load order decides.
+func (lib *library) compendiumSlug(file, name string) string {
+	if c := lib.kept[file]; c != nil && c.Name == name {
+		return c.Slug
+	}
+	taken := func(s string) bool {
+		if lib.slugs[s] != nil {
+			return true
+		}
+		for f, c := range lib.kept {
+			if f != file && c.Slug == s {
+				return true
+			}
+		}
+		return false
+	}
+	s := slug(name)
+	for n := 2; taken(s); n++ {
+		s = slug(name) + "-" + strconv.Itoa(n)
+	}
+	return s
+}
+
+// addCompendium adds a prepared compendium to the maps of lib. It only
+// reads c.
+func (lib *library) addCompendium(c *Compendium) {
+	if other, ok := lib.compendiums[c.Name]; ok {
+		log.Printf("ERROR: Skipping file %q because compendium %q is already loaded from %q", c.File, c.Name, other.File)
+		return
+	}
+	if base := filepath.Base(c.File); c.Name == base && filepath.Ext(base) != "" {
+		log.Printf("ERROR: Another file has the same name as %q; calling its compendium %q", c.File, c.Name)
+	}
+	if other, ok := lib.slugs[c.Slug]; ok {
+		log.Printf("ERROR: Skipping file %q because monster IDs %q/... are already used by %q", c.File, c.Slug, other.File)
+		return
+	}
+	if c.Slug != slug(c.Name) {
+		log.Printf("ERROR: Another file has the same monster IDs as %q; starting its IDs with %q", c.File, c.Slug+"/")
+	}
+	lib.compendiums[c.Name] = c
+	lib.slugs[c.Slug] = c
+	for _, m := range c.Monsters {
+		lib.monsters[m.Name+" ("+c.Name+")"] = m
+		lib.byId[m.Id] = m
+	}
+	for _, s := range c.Spells {
+		lib.spells[s.Name+" ("+c.Name+")"] = s
+	}
+}
+
+// changed reports whether the data files differ from the ones lib loaded.
+func (lib *library) changed(dir string) bool {
+	stamps, err := dataFiles(dir)
+	if err != nil {
+		log.Printf("ERROR: Could not check data directory: %s", err)
+		return false
+	}
+	if len(stamps) != len(lib.files) {
+		return true
+	}
+	for file, stamp := range stamps {
+		if old, ok := lib.files[file]; !ok || old != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// library returns the currently loaded data.
+func (es *EncounterServer) library() *library {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	return es.lib
+}
+
+// Reload loads the data directory again and swaps it in. Requests that are
+// in flight keep using the data they started with.
+func (es *EncounterServer) Reload() (*library, error) {
+	es.reloadMu.Lock()
+	defer es.reloadMu.Unlock()
+	lib, err := loadLibrary(es.dir, es.library())
+	if err != nil {
+		return nil, err
+	}
+	es.mu.Lock()
+	es.lib = lib
+	es.mu.Unlock()
+	log.Printf("Loaded %d compendiums with %d monsters", len(lib.compendiums), len(lib.monsters))
+	return lib, nil
+}
+
+// watch reloads the data directory when its files change, checking every
+// interval, and whenever the process receives SIGHUP.
+func (es *EncounterServer) watch(interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	var tick <-chan time.Time
+	if interval > 0 {
+		tick = time.NewTicker(interval).C
+	}
+	for {
+		select {
+		case <-hup:
+			log.Printf("Reloading data on SIGHUP")
+		case <-tick:
+			if !es.library().changed(es.dir) {
+				continue
+			}
+			log.Printf("Reloading changed data")
+		}
+		if _, err := es.Reload(); err != nil {
+			log.Printf("ERROR: Could not reload data: %s", err)
+		}
+	}
+}
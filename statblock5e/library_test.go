@@ -0,0 +1,248 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testCompendium = `<?xml version="1.0" encoding="UTF-8"?>
+<compendium version="5">
+	<monster>
+		<name>Goblin</name>
+		<size>S</size>
+		<type>humanoid (goblinoid)</type>
+		<alignment>neutral evil</alignment>
+		<ac>15 (leather armor, shield)</ac>
+		<hp>7 (2d6)</hp>
+		<speed>30 ft.</speed>
+		<str>8</str>
+		<dex>14</dex>
+		<con>10</con>
+		<int>10</int>
+		<wis>8</wis>
+		<cha>8</cha>
+		<senses>darkvision 60 ft.</senses>
+		<cr>1/4</cr>
+		<trait>
+			<name>Nimble Escape</name>
+			<text>The goblin can take the Disengage or Hide action as a bonus action on each of its turns.</text>
+		</trait>
+		<action>
+			<name>Scimitar</name>
+			<text>Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 5 (1d6 + 2) slashing damage.</text>
+		</action>
+	</monster>
+	<monster>
+		<name>Wolf</name>
+		<size>M</size>
+		<type>beast</type>
+		<ac>13 (natural armor)</ac>
+		<hp>11 (2d8+2)</hp>
+		<speed>40 ft.</speed>
+		<str>12</str>
+		<dex>15</dex>
+		<con>12</con>
+		<int>3</int>
+		<wis>12</wis>
+		<cha>6</cha>
+		<cr>1/4</cr>
+		<trait>
+			<name>Pack Tactics</name>
+			<text>The wolf has advantage on an attack roll against a creature if at least one of the wolf's allies is within 5 feet of the creature and the ally isn't incapacitated.</text>
+		</trait>
+	</monster>
+</compendium>
+`
+
+// writeTestData creates a data directory with the given files and returns
+// its root.
+func writeTestData(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "statblock5e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	if err := os.Mkdir(filepath.Join(dir, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, "data", name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReloadWhileServing(t *testing.T) {
+	dir := writeTestData(t, map[string]string{
+		"Bestiary.xml": testCompendium,
+		"Other.xml":    testCompendium,
+	})
+	es, err := NewEncounterServer(":0", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es.routes()
+	ts := httptest.NewServer(es.server)
+	defer ts.Close()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, path := range []string{
+		"/api/monsters",
+		"/api/monsters?format=csv",
+		"/api/monsters?search=goblin",
+		"/api/monsters/bestiary/goblin",
+		"/api/monsters/bestiary/wolf/foundry",
+		"/monsters/other/goblin",
+	} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				resp, err := http.Get(ts.URL + path)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("GET %s: %s", path, resp.Status)
+					return
+				}
+			}
+		}(path)
+	}
+
+	// Touch one file at a time so that every reload reuses the other one.
+	for i := 0; i < 20; i++ {
+		file := filepath.Join(dir, "data", "Other.xml")
+		if i%2 == 1 {
+			file = filepath.Join(dir, "data", "Bestiary.xml")
+		}
+		stamp := time.Now().Add(time.Duration(i) * time.Second)
+		if err := os.Chtimes(file, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+		lib, err := es.Reload()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lib.monsters) != 4 {
+			t.Fatalf("Reload loaded %d monsters, want 4", len(lib.monsters))
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestReloadReusesUnchangedFiles(t *testing.T) {
+	dir := writeTestData(t, map[string]string{
+		"Bestiary.xml": testCompendium,
+		"Other.xml":    testCompendium,
+	})
+	first, err := loadLibrary(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "data", "Other.xml")
+	stamp := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+	if !first.changed(dir) {
+		t.Errorf("changed() = false after touching %q", file)
+	}
+	second, err := loadLibrary(dir, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.compendiums["Bestiary"] != first.compendiums["Bestiary"] {
+		t.Errorf("Unchanged compendium was loaded again")
+	}
+	if second.compendiums["Other"] == first.compendiums["Other"] {
+		t.Errorf("Changed compendium was not loaded again")
+	}
+	if m := second.byId["other/wolf"]; m == nil || m.Source != file {
+		t.Errorf("Reloaded monster %v does not have source %q", m, file)
+	}
+}
+
+func TestCompendiumNameCollision(t *testing.T) {
+	dir := writeTestData(t, map[string]string{
+		"goblins.xml": testCompendium,
+		"goblins.md":  "___\n> ## Goblin Boss\n>*Small humanoid (goblinoid), neutral evil*\n> - **Challenge** 1 (200 XP)\n",
+	})
+	lib, err := loadLibrary(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := lib.compendiums["goblins"]; c == nil || filepath.Ext(c.File) != ".xml" {
+		t.Errorf("Compendium %q is %v, want the XML file", "goblins", c)
+	}
+	if c := lib.compendiums["goblins.md"]; c == nil || len(c.Monsters) != 1 {
+		t.Fatalf("Compendium %q is %v, want the markdown file", "goblins.md", c)
+	}
+	if lib.monsters["Goblin (goblins)"] == nil || lib.monsters["Goblin Boss (goblins.md)"] == nil {
+		t.Errorf("Monsters of both files are not all loaded: %v", lib.monsters)
+	}
+	// The autocomplete builds the keys from Name and Compendium.
+	for key, m := range lib.monsters {
+		if got := m.Name + " (" + m.Compendium + ")"; got != key {
+			t.Errorf("Monster %q has key %q", key, got)
+		}
+	}
+
+	// A reload that changes nothing keeps both names and reuses both files.
+	again, err := loadLibrary(dir, lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range lib.compendiums {
+		if again.compendiums[name] != c {
+			t.Errorf("Reload did not reuse compendium %q", name)
+		}
+	}
+}
+
+func TestCompendiumNameKeptOnReload(t *testing.T) {
+	dir := writeTestData(t, map[string]string{
+		"goblins.md": "___\n> ## Goblin Boss\n>*Small humanoid (goblinoid), neutral evil*\n> - **Challenge** 1 (200 XP)\n",
+	})
+	lib, err := loadLibrary(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boss := lib.monsters["Goblin Boss (goblins)"]
+	if boss == nil {
+		t.Fatalf("Goblin Boss not loaded: %v", lib.monsters)
+	}
+
+	// goblins.xml loads first, but doesn't take the name of goblins.md.
+	if err := ioutil.WriteFile(filepath.Join(dir, "data", "goblins.xml"), []byte(testCompendium), 0644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := loadLibrary(dir, lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.monsters["Goblin Boss (goblins)"] != boss {
+		t.Errorf("Goblin Boss (goblins) is %v, want the monster of goblins.md", again.monsters["Goblin Boss (goblins)"])
+	}
+	if c := again.compendiums["goblins.xml"]; c == nil || again.monsters["Goblin (goblins.xml)"] == nil {
+		t.Errorf("Compendium %q is %v, want the XML file", "goblins.xml", c)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// This file reads and writes stat blocks in the Homebrewery and GM Binder
+// markdown syntax:
+//
+//	___
+//	> ## Goblin
+//	>*Small humanoid (goblinoid), neutral evil*
+//	> ___
+//	> - **Armor Class** 15 (leather armor, shield)
+//	...
+//	> ### Actions
+//	> ***Scimitar.*** *Melee Weapon Attack:* +4 to hit, ...
+//
+// Like the other importers, each stat block is rendered into the Lion's Den
+// text fields and then parsed as usual. Plain paragraphs that follow a stat
+// block are its description.
+
+var (
+	mdTraitRe    = regexp.MustCompile(`^(?:\*\*\*|\*\*_|_\*\*)(.+?)(?:\*\*\*|_\*\*|\*\*_)\s*(.*)$`)
+	mdPropertyRe = regexp.MustCompile(`^(?:[-*]\s+)?\*\*([^*]+?)\*\*\s*(.*)$`)
+	mdScoreRe    = regexp.MustCompile(`^\s*(\d+)`)
+	mdAttackRe   = regexp.MustCompile(`(Melee or Ranged|Melee|Ranged) (Weapon|Spell) Attack:|Hit:`)
+	mdCleaner    = strings.NewReplacer("*", "", "_", "", "&nbsp;", " ", "<br>", " ")
+)
+
+// LoadHomebreweryCompendium loads every stat block in a Homebrewery or GM
+// Binder markdown file.
+func LoadHomebreweryCompendium(path string) (*Compendium, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load compendium from file %q: %s", path, err)
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	monsters, warnings, err := ParseHomebrewery(f)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse markdown file %q: %s", path, err)
+	}
+	for _, w := range warnings {
+		log.Printf("WARNING: %q: %s", name, w)
+	}
+	c := &Compendium{Name: name, File: path, Monsters: monsters}
+	for _, m := range c.Monsters {
+		m.Source = name
+		for _, err := range m.Parse() {
+			log.Printf("WARNING: Monster %q in %q: %s", m.Name, name, err)
+		}
+	}
+	return c, nil
+}
+
+// ParseHomebrewery reads the stat blocks in markdown. The monsters are not
+// parsed yet; the warnings list lines that were not understood.
+func ParseHomebrewery(r io.Reader) ([]*Monster, []string, error) {
+	p := &mdParser{}
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		p.line(s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return nil, nil, err
+	}
+	p.finish()
+	return p.monsters, p.warnings, nil
+}
+
+type mdParser struct {
+	statBlockBuilder
+	inBlock   bool
+	abilities []string
+}
+
+func (p *mdParser) line(raw string) {
+	trimmed := strings.TrimSpace(raw)
+	if !strings.HasPrefix(trimmed, ">") {
+		p.inBlock = false
+		p.trait = nil
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			// A new document section ends the description.
+			p.finish()
+		case p.m != nil && trimmed != "" && trimmed != "___" && !strings.HasPrefix(trimmed, "\\"):
+			p.m.Description = strings.TrimSpace(p.m.Description + "\n" + mdClean(trimmed))
+		}
+		return
+	}
+
+	text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+	if strings.HasPrefix(text, "## ") {
+		p.finish()
+		p.m = &Monster{Name: mdClean(text[3:])}
+		p.inBlock, p.section, p.trait, p.abilities = true, "", nil, nil
+		return
+	}
+	if p.m == nil || !p.inBlock {
+		// A blockquote that is not a stat block.
+		return
+	}
+
+	switch {
+	case text == "" || strings.Trim(text, "_-") == "":
+	case strings.HasPrefix(text, "### "):
+		p.startSection(mdClean(text[4:]))
+	case strings.HasPrefix(text, "|"):
+		p.tableRow(text)
+	case p.m.Type == "" && p.m.Alignment == "" && len(p.m.Traits) == 0 && strings.HasPrefix(text, "*") && !strings.HasPrefix(text, "**"):
+		p.subtitle(mdClean(text))
+	case mdTraitRe.MatchString(text):
+		t := mdTraitRe.FindStringSubmatch(text)
+		p.addTrait(strings.TrimSuffix(strings.TrimSpace(mdClean(t[1])), "."), mdClean(t[2]))
+	case mdPropertyRe.MatchString(text):
+		t := mdPropertyRe.FindStringSubmatch(text)
+		name := strings.TrimSuffix(mdClean(t[1]), ":")
+		if strings.HasSuffix(name, ".") {
+			// Some documents bold trait names with only two asterisks.
+			p.addTrait(strings.TrimSuffix(name, "."), mdClean(t[2]))
+			return
+		}
+		p.property(name, mdClean(t[2]))
+	default:
+		text = mdClean(strings.TrimPrefix(strings.TrimPrefix(text, "- "), "* "))
+		if p.trait == nil {
+			p.warn("Ignoring text %q", text)
+			return
+		}
+		p.trait.Text = append(p.trait.Text, text)
+	}
+}
+
+func mdClean(s string) string {
+	return strings.TrimSpace(mdCleaner.Replace(s))
+}
+
+func (p *mdParser) tableRow(row string) {
+	var cells []string
+	for _, c := range strings.Split(strings.Trim(row, "|"), "|") {
+		cells = append(cells, strings.TrimSpace(mdClean(c)))
+	}
+	if len(cells) > 0 && abilityName(cells[0]) != "" {
+		p.abilities = nil
+		for _, c := range cells {
+			p.abilities = append(p.abilities, abilityName(c))
+		}
+		return
+	}
+	if len(cells) > 0 && strings.Trim(cells[0], ":-") == "" {
+		return
+	}
+	for i, c := range cells {
+		if i >= len(p.abilities) {
+			break
+		}
+		s := mdScoreRe.FindStringSubmatch(c)
+		if s == nil {
+			p.warn("Invalid ability score %q", c)
+			continue
+		}
+		switch p.abilities[i] {
+		case "Str":
+			p.m.Str = s[1]
+		case "Dex":
+			p.m.Dex = s[1]
+		case "Con":
+			p.m.Con = s[1]
+		case "Int":
+			p.m.Int = s[1]
+		case "Wis":
+			p.m.Wis = s[1]
+		case "Cha":
+			p.m.Cha = s[1]
+		}
+	}
+}
+
+// WriteHomebrewery writes the monster as a Homebrewery stat block, followed
+// by its description.
+func (m *Monster) WriteHomebrewery(w io.Writer) error {
+	var b strings.Builder
+	line := func(s string) { b.WriteString(">" + s + "\n") }
+	property := func(name, value string) {
+		if value != "" {
+			line(" - **" + name + "** " + value)
+		}
+	}
+
+	b.WriteString("___\n")
+	line(" ## " + m.Name)
+	line("*" + strings.TrimSpace(m.Subtitle()) + "*")
+	line(" ___")
+	property("Armor Class", m.Ac)
+	property("Hit Points", m.Hp)
+	property("Speed", m.Speed)
+	line("___")
+	line("|STR|DEX|CON|INT|WIS|CHA|")
+	line("|:---:|:---:|:---:|:---:|:---:|:---:|")
+	var scores []string
+	for _, name := range AbilityNames {
+		scores = append(scores, m.Abilities.Get(name).String())
+	}
+	line("|" + strings.Join(scores, "|") + "|")
+	line("___")
+	property("Saving Throws", m.Save)
+	property("Skills", m.Skill)
+	property("Damage Vulnerabilities", m.Vulnerabilities)
+	property("Damage Resistances", m.Resistances)
+	property("Damage Immunities", m.DamageImmunity)
+	property("Condition Immunities", m.ConditionImmunity)
+	senses := m.Senses
+	if senses != "" {
+		senses += ", "
+	}
+	property("Senses", senses+"passive Perception "+strconv.Itoa(m.PassivePerception))
+	languages := m.Languages
+	if languages == "" {
+		languages = "—"
+	}
+	property("Languages", languages)
+	property("Challenge", m.ChallengeText())
+	if m.ProficiencyBonus > 0 {
+		property("Proficiency Bonus", formatBonus(m.ProficiencyBonus))
+	}
+	line(" ___")
+
+	traits := func(list []Trait) {
+		for i, t := range list {
+			if i > 0 {
+				line("")
+			}
+			heading := strings.Replace(t.Heading(), " (Bonus Action)", "", 1)
+			text := mdAttackText(t.Text)
+			if len(text) == 0 {
+				text = []string{""}
+			}
+			line(" ***" + heading + ".*** " + text[0])
+			for _, s := range text[1:] {
+				line("")
+				line(" " + s)
+			}
+		}
+	}
+	section := func(heading string, intro []string, list []Trait) {
+		if len(intro) == 0 && len(list) == 0 {
+			return
+		}
+		line("")
+		line(" ### " + heading)
+		for _, s := range intro {
+			line(" " + s)
+			line("")
+		}
+		traits(list)
+	}
+
+	var actions, bonus []Trait
+	for _, t := range m.Actions {
+		if strings.HasSuffix(t.Name, " (Bonus Action)") {
+			bonus = append(bonus, t)
+		} else {
+			actions = append(actions, t)
+		}
+	}
+	traits(m.Traits)
+	section("Actions", nil, actions)
+	section("Bonus Actions", nil, bonus)
+	section("Reactions", nil, m.Reactions)
+	section("Legendary Actions", m.LegendaryIntro, m.LegendaryActions)
+	section("Lair Actions", m.LairIntro, m.LairActions)
+	section("Regional Effects", m.RegionalIntro, m.RegionalEffects)
+
+	if m.Description != "" {
+		b.WriteString("\n")
+		for _, s := range strings.Split(m.Description, "\n") {
+			if s = strings.TrimSpace(s); s != "" {
+				b.WriteString(s + "\n\n")
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdAttackText italicizes the attack type and "Hit:" the way the books and
+// Homebrewery do.
+func mdAttackText(text []string) []string {
+	f := make([]string, len(text))
+	for i, s := range text {
+		f[i] = mdAttackRe.ReplaceAllString(s, "*$0*")
+	}
+	return f
+}
+
+// WriteHomebrewery writes the monsters as Homebrewery stat blocks. Each
+// monster is written once.
+func WriteHomebrewery(w io.Writer, monsters []*Monster) error {
+	seen := make(map[*Monster]bool)
+	for _, m := range monsters {
+		if m == nil || seen[m] {
+			continue
+		}
+		seen[m] = true
+		if err := m.WriteHomebrewery(w); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHomebrewery writes the monsters of the encounter as Homebrewery stat
+// blocks.
+func (e *Encounter) WriteHomebrewery(w io.Writer) error {
+	var monsters []*Monster
+	for _, m := range e.Monsters {
+		monsters = append(monsters, m.Monster)
+	}
+	return WriteHomebrewery(w, monsters)
+}
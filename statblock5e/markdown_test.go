@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const mdBugbear = `# Goblinoids
+
+> A quote that is not a stat block.
+
+___
+> ## Bugbear
+>*Medium humanoid (goblinoid), chaotic evil*
+> ___
+> - **Armor Class** 16 (hide armor, shield)
+> - **Hit Points** 27 (5d8+5)
+> - **Speed** 30 ft.
+>___
+>|STR|DEX|CON|INT|WIS|CHA|
+>|:---:|:---:|:---:|:---:|:---:|:---:|
+>|15 (+2)|14 (+2)|13 (+1)|8 (–1)|11 (+0)|9 (–1)|
+>___
+> - **Skills** Stealth +6, Survival +2
+> - **Senses** darkvision 60 ft., passive Perception 10
+> - **Languages** Common, Goblin
+> - **Challenge** 1 (200 XP)
+> ___
+> ***Brute.*** A melee weapon deals one extra die of its damage when the bugbear hits with it (included in the attack).
+>
+> **Surprise Attack.** If the bugbear surprises a creature, the target takes an extra 7 (2d6) damage.
+>
+> ### Actions
+> ***Morningstar.*** *Melee Weapon Attack:* +4 to hit, reach 5 ft., one target. *Hit:* 11 (2d8 + 2) piercing damage.
+>
+> ***Javelin.*** *Melee or Ranged Weapon Attack:* +4 to hit, reach 5 ft. or range 30/120 ft., one target.
+> *Hit:* 9 (2d6 + 2) piercing damage in melee or 5 (1d6 + 2) piercing damage at range.
+>
+> ### Reactions
+> ***Shield Wall (1/Day).*** The bugbear adds 2 to its AC against one attack.
+
+Bugbears are born for battle and mayhem.
+
+They hate *goblins*.
+
+## Appendix
+
+This is not about bugbears.
+`
+
+func TestParseHomebrewery(t *testing.T) {
+	monsters, warnings, err := ParseHomebrewery(strings.NewReader(mdBugbear))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("Warnings %q", warnings)
+	}
+	if len(monsters) != 1 {
+		t.Fatalf("ParseHomebrewery found %d monsters, want 1", len(monsters))
+	}
+	m := monsters[0]
+	got := Monster{Name: m.Name, Size: m.Size, Type: m.Type, Alignment: m.Alignment, Ac: m.Ac, Hp: m.Hp, Speed: m.Speed,
+		Str: m.Str, Dex: m.Dex, Con: m.Con, Int: m.Int, Wis: m.Wis, Cha: m.Cha,
+		Skill: m.Skill, Senses: m.Senses, Passive: m.Passive, Languages: m.Languages, Cr: m.Cr,
+		Description: m.Description}
+	want := Monster{Name: "Bugbear", Size: "M", Type: "humanoid (goblinoid)", Alignment: "chaotic evil",
+		Ac: "16 (hide armor, shield)", Hp: "27 (5d8+5)", Speed: "30 ft.",
+		Str: "15", Dex: "14", Con: "13", Int: "8", Wis: "11", Cha: "9",
+		Skill: "Stealth +6, Survival +2", Senses: "darkvision 60 ft.", Passive: "10", Languages: "Common, Goblin", Cr: "1",
+		Description: "Bugbears are born for battle and mayhem.\nThey hate goblins."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parsed\n%+v\nwant\n%+v", got, want)
+	}
+	if names := traitNames(m.Traits); !reflect.DeepEqual(names, []string{"Brute", "Surprise Attack"}) {
+		t.Errorf("Traits %q", names)
+	}
+	if names := traitNames(m.Actions); !reflect.DeepEqual(names, []string{"Morningstar", "Javelin"}) {
+		t.Errorf("Actions %q", names)
+	}
+	if names := traitNames(m.Reactions); !reflect.DeepEqual(names, []string{"Shield Wall (1/Day)"}) {
+		t.Errorf("Reactions %q", names)
+	}
+	if text := m.Actions[0].Text[0]; text != "Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 11 (2d8 + 2) piercing damage." {
+		t.Errorf("Morningstar text %q", text)
+	}
+	if len(m.Actions[1].Text) != 2 {
+		t.Errorf("Javelin text %q, want 2 lines", m.Actions[1].Text)
+	}
+}
+
+func TestParseHomebreweryWarnings(t *testing.T) {
+	_, warnings, err := ParseHomebrewery(strings.NewReader("> ## Blob\n>*Large ooze, unaligned*\n> Some stray text.\n>|STR|DEX|\n>|big|10|\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := strings.Join(warnings, "\n")
+	for _, want := range []string{`Ignoring text "Some stray text."`, `Invalid ability score "big"`} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Warnings %q, want %q", warnings, want)
+		}
+	}
+}
+
+func TestHomebreweryRoundTrip(t *testing.T) {
+	monsters, _, err := ParseHomebrewery(strings.NewReader(mdBugbear))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := monsters[0]
+	m.Parse()
+
+	var b bytes.Buffer
+	if err := WriteHomebrewery(&b, []*Monster{m, m, nil}); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "> ## Bugbear"); n != 1 {
+		t.Errorf("WriteHomebrewery wrote the bugbear %d times, want once", n)
+	}
+	again, warnings, err := ParseHomebrewery(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 || len(again) != 1 {
+		t.Fatalf("Reading back found %d monsters with warnings %q\n%s", len(again), warnings, b.String())
+	}
+	r := again[0]
+	r.Parse()
+	if r.Name != m.Name || r.Subtitle() != m.Subtitle() || r.Ac != m.Ac || r.Hp != m.Hp || r.Speed != m.Speed ||
+		r.Abilities != m.Abilities || r.Skill != m.Skill || r.Senses != m.Senses || r.PassivePerception != m.PassivePerception ||
+		r.Languages != m.Languages || r.ChallengeText() != m.ChallengeText() || r.Description != m.Description {
+		t.Errorf("Round trip gave\n%+v\nwant\n%+v", r, m)
+	}
+	for _, c := range []struct {
+		section   string
+		got, want []Trait
+	}{
+		{"traits", r.Traits, m.Traits},
+		{"actions", r.Actions, m.Actions},
+		{"reactions", r.Reactions, m.Reactions},
+	} {
+		if len(c.got) != len(c.want) {
+			t.Errorf("Round trip %s %q, want %q", c.section, traitNames(c.got), traitNames(c.want))
+			continue
+		}
+		for i := range c.got {
+			if c.got[i].Heading() != c.want[i].Heading() || !reflect.DeepEqual(c.got[i].Text, c.want[i].Text) ||
+				!reflect.DeepEqual(c.got[i].Attack, c.want[i].Attack) {
+				t.Errorf("Round trip of %q gave %+v, want %+v", c.want[i].Name, c.got[i], c.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// splitList splits a comma or semicolon separated list, ignoring separators
+// that appear inside parentheses. Items are trimmed and empty items dropped.
+func splitList(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	add := func(end int) {
+		if item := strings.TrimSpace(s[start:end]); item != "" {
+			items = append(items, item)
+		}
+	}
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',', ';':
+			if depth == 0 {
+				add(i)
+				start = i + 1
+			}
+		}
+	}
+	add(len(s))
+	return items
+}
+
+// jsonString, jsonNumber and jsonList read values from JSON that was decoded
+// into interface{}, accepting the loose typing of community data files.
+func jsonString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return ""
+}
+
+func jsonNumber(v interface{}) float64 {
+	switch t := v.(type) {
+	case float64:
+		return t
+	case string:
+		f, _ := strconv.ParseFloat(t, 64)
+		return f
+	}
+	return 0
+}
+
+func jsonList(v interface{}) []interface{} {
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+	if v == nil {
+		return nil
+	}
+	return []interface{}{v}
+}
+
+// formatBonus prints a bonus with a plain sign, e.g. "+5" or "-1".
+func formatBonus(i int) string {
+	if i < 0 {
+		return strconv.Itoa(i)
+	}
+	return "+" + strconv.Itoa(i)
+}
+
+// joinAnd joins a list the way the books do: "a, b, and c".
+func joinAnd(l []string) string {
+	switch len(l) {
+	case 0:
+		return ""
+	case 1:
+		return l[0]
+	case 2:
+		return l[0] + " and " + l[1]
+	}
+	return strings.Join(l[:len(l)-1], ", ") + ", and " + l[len(l)-1]
+}
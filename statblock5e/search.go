@@ -0,0 +1,365 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// searchIndex is an inverted index over the monsters of a library: for each
+// word, where it appears in names, types, traits and actions. It is built
+// once per load, like the rest of the library.
+//
+// Queries are words, "quoted phrases" and either of them after a field
+// prefix, e.g. `trait:"pack tactics" action:breath dragon`. Every part must
+// match. Unquoted words also match longer words they start, so that the
+// autocomplete finds "goblin" from "gob", and words of names they are part
+// of, so that "blin" still finds "goblin", if with a lower score. Words are
+// reduced to a simple stem, so that "invisible" also finds "invisibility".
+type searchIndex struct {
+	monsters []*Monster
+	postings map[string][]posting
+	// terms are the keys of postings, sorted, for prefix lookups.
+	terms []string
+	// docs is the number of monsters each word appears in.
+	docs map[string]int
+	// trigrams lists, for each three letters, the words of names that
+	// contain them, for substring lookups.
+	trigrams map[string][]string
+}
+
+// posting is one occurrence of a word. Positions of separate traits and
+// lines are far apart so that phrases don't match across them.
+type posting struct {
+	doc     int
+	field   int
+	heading bool
+	pos     int
+}
+
+const (
+	fieldName = iota
+	fieldType
+	fieldTrait
+	fieldAction
+	fieldReaction
+	fieldLegendary
+	fieldLair
+)
+
+// searchFields are the field prefixes of queries.
+var searchFields = map[string]int{
+	"name":      fieldName,
+	"type":      fieldType,
+	"trait":     fieldTrait,
+	"action":    fieldAction,
+	"reaction":  fieldReaction,
+	"legendary": fieldLegendary,
+	"lair":      fieldLair,
+}
+
+// fieldWeights rank a match in the name above one in the type, and that
+// above one in the text of a trait or action. Trait and action names count
+// headingWeight times their text.
+var fieldWeights = []float64{
+	fieldName:      8,
+	fieldType:      3,
+	fieldTrait:     1,
+	fieldAction:    1,
+	fieldReaction:  1,
+	fieldLegendary: 1,
+	fieldLair:      1,
+}
+
+const (
+	headingWeight = 3
+	// prefixWeight scales matches of longer words that start with the
+	// query word.
+	prefixWeight = 0.5
+	// substringWeight scales matches of name words that contain the query
+	// word elsewhere than at their start. Shorter query words don't match
+	// this way, as they would be in most names.
+	substringWeight = 0.25
+	minSubstring    = 3
+	// positionGap separates the traits and lines of a field.
+	positionGap = 1000
+)
+
+func newSearchIndex(monsters []*Monster) *searchIndex {
+	idx := &searchIndex{
+		monsters: monsters,
+		postings: make(map[string][]posting),
+		docs:     make(map[string]int),
+		trigrams: make(map[string][]string),
+	}
+	names := make(map[string]bool)
+	for doc, m := range monsters {
+		pos := 0
+		add := func(field int, heading bool, text string) {
+			for _, t := range searchTerms(text) {
+				if l := idx.postings[t]; len(l) == 0 || l[len(l)-1].doc != doc {
+					idx.docs[t]++
+				}
+				idx.postings[t] = append(idx.postings[t], posting{doc, field, heading, pos})
+				if field == fieldName {
+					names[t] = true
+				}
+				pos++
+			}
+			pos += positionGap
+		}
+		traits := func(field int, list []Trait) {
+			for _, t := range list {
+				add(field, true, t.Name)
+				for _, s := range t.Text {
+					add(field, false, s)
+				}
+			}
+		}
+		add(fieldName, false, m.Name)
+		add(fieldType, false, m.Type)
+		traits(fieldTrait, m.Traits)
+		traits(fieldAction, m.Actions)
+		traits(fieldReaction, m.Reactions)
+		traits(fieldLegendary, m.LegendaryActions)
+		traits(fieldLair, m.LairActions)
+		traits(fieldLair, m.RegionalEffects)
+	}
+	for t := range idx.postings {
+		idx.terms = append(idx.terms, t)
+	}
+	sort.Strings(idx.terms)
+	for _, t := range idx.terms {
+		if !names[t] {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, g := range trigrams(t) {
+			if !seen[g] {
+				seen[g] = true
+				idx.trigrams[g] = append(idx.trigrams[g], t)
+			}
+		}
+	}
+	return idx
+}
+
+// trigrams returns every three letters in a row of w.
+func trigrams(w string) []string {
+	r := []rune(w)
+	var l []string
+	for i := 0; i+3 <= len(r); i++ {
+		l = append(l, string(r[i:i+3]))
+	}
+	return l
+}
+
+// searchTerms splits text into lowercase, stemmed words.
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = stem(w)
+	}
+	return words
+}
+
+// stem removes the few English endings that matter for stat blocks: plurals
+// and "-ility" nouns of "-ible" and "-able" adjectives.
+func stem(w string) string {
+	switch {
+	case strings.HasSuffix(w, "ibility"):
+		return strings.TrimSuffix(w, "ibility") + "ible"
+	case strings.HasSuffix(w, "ability"):
+		return strings.TrimSuffix(w, "ability") + "able"
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return strings.TrimSuffix(w, "ies") + "y"
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") &&
+		!strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		return strings.TrimSuffix(w, "s")
+	}
+	return w
+}
+
+// searchClause is one part of a query: a word or a phrase, in one field or
+// in all of them.
+type searchClause struct {
+	field  int // -1 for all fields
+	terms  []string
+	prefix bool
+}
+
+// parseQuery splits a query into clauses. Unknown field prefixes are
+// searched for as words.
+func parseQuery(q string) []searchClause {
+	var clauses []searchClause
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		field := -1
+		if i := strings.IndexAny(q, ": \""); i > 0 && q[i] == ':' {
+			if f, ok := searchFields[strings.ToLower(q[:i])]; ok {
+				field = f
+				q = q[i+1:]
+			}
+		}
+		var text string
+		phrase := strings.HasPrefix(q, "\"")
+		if phrase {
+			q = q[1:]
+			end := strings.Index(q, "\"")
+			if end < 0 {
+				end = len(q)
+			}
+			text, q = q[:end], strings.TrimPrefix(q[end:], "\"")
+		} else {
+			end := strings.IndexFunc(q, unicode.IsSpace)
+			if end < 0 {
+				end = len(q)
+			}
+			text, q = q[:end], q[end:]
+		}
+		terms := searchTerms(text)
+		if len(terms) == 0 {
+			continue
+		}
+		// Words like "half-dragon" are phrases of their parts.
+		clauses = append(clauses, searchClause{field, terms, !phrase && len(terms) == 1})
+	}
+	return clauses
+}
+
+// searchResult is a monster found by Search with its score.
+type searchResult struct {
+	Monster *Monster
+	Score   float64
+}
+
+// Search returns the monsters that match every clause of the query, best
+// first. Scores add up the matches of each clause, weighted by field and by
+// how rare the word is (its inverse document frequency).
+func (idx *searchIndex) Search(query string) []searchResult {
+	clauses := parseQuery(query)
+	if len(clauses) == 0 {
+		return nil
+	}
+	var scores map[int]float64
+	for _, c := range clauses {
+		s := idx.score(c)
+		if scores == nil {
+			scores = s
+			continue
+		}
+		for doc := range scores {
+			if v, ok := s[doc]; ok {
+				scores[doc] += v
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+	results := make([]searchResult, 0, len(scores))
+	for doc, s := range scores {
+		results = append(results, searchResult{idx.monsters[doc], s})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Monster.Name < results[j].Monster.Name
+	})
+	return results
+}
+
+// score returns the score of every monster that matches the clause.
+func (idx *searchIndex) score(c searchClause) map[int]float64 {
+	scores := make(map[int]float64)
+	add := func(term string, postings []posting, w float64) {
+		w *= idx.idf(term)
+		for _, p := range postings {
+			if c.field < 0 || p.field == c.field {
+				scores[p.doc] += w * p.weight()
+			}
+		}
+	}
+	term := c.terms[0]
+	if len(c.terms) > 1 {
+		add(term, idx.phrase(c), 1)
+	} else {
+		add(term, idx.postings[term], 1)
+	}
+	if c.prefix {
+		for i := sort.SearchStrings(idx.terms, term); i < len(idx.terms) && strings.HasPrefix(idx.terms[i], term); i++ {
+			if idx.terms[i] != term {
+				add(idx.terms[i], idx.postings[idx.terms[i]], prefixWeight)
+			}
+		}
+	}
+	if c.prefix && len([]rune(term)) >= minSubstring && (c.field < 0 || c.field == fieldName) {
+		for _, t := range idx.substringCandidates(term) {
+			if !strings.Contains(t, term) || strings.HasPrefix(t, term) {
+				continue
+			}
+			w := substringWeight * idx.idf(t)
+			for _, p := range idx.postings[t] {
+				if p.field == fieldName {
+					scores[p.doc] += w * p.weight()
+				}
+			}
+		}
+	}
+	// Dampen repeated matches so that long texts don't win on length.
+	for doc, s := range scores {
+		scores[doc] = math.Log1p(s)
+	}
+	return scores
+}
+
+// phrase returns the postings of the first word of each occurrence of the
+// phrase.
+func (idx *searchIndex) phrase(c searchClause) []posting {
+	var next map[[2]int]bool
+	var found []posting
+	for i := len(c.terms) - 1; i >= 0; i-- {
+		cur := make(map[[2]int]bool)
+		found = nil
+		for _, p := range idx.postings[c.terms[i]] {
+			if c.field >= 0 && p.field != c.field {
+				continue
+			}
+			if next != nil && !next[[2]int{p.doc, p.pos + 1}] {
+				continue
+			}
+			cur[[2]int{p.doc, p.pos}] = true
+			found = append(found, p)
+		}
+		next = cur
+	}
+	return found
+}
+
+// weight is how much the field of the occurrence counts.
+func (p posting) weight() float64 {
+	if p.heading {
+		return fieldWeights[p.field] * headingWeight
+	}
+	return fieldWeights[p.field]
+}
+
+// substringCandidates returns the name words that may contain term: those
+// with its rarest three letters.
+func (idx *searchIndex) substringCandidates(term string) []string {
+	var candidates []string
+	for i, g := range trigrams(term) {
+		l := idx.trigrams[g]
+		if i == 0 || len(l) < len(candidates) {
+			candidates = l
+		}
+	}
+	return candidates
+}
+
+// idf is high for words that few monsters have.
+func (idx *searchIndex) idf(term string) float64 {
+	return math.Log(1 + float64(len(idx.monsters))/float64(idx.docs[term]+1))
+}
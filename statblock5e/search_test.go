@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want []searchClause
+	}{
+		{"goblin", []searchClause{{-1, []string{"goblin"}, true}}},
+		{"  Goblins  Wolf ", []searchClause{{-1, []string{"goblin"}, true}, {-1, []string{"wolf"}, true}}},
+		{`"pack tactics"`, []searchClause{{-1, []string{"pack", "tactic"}, false}}},
+		{`trait:"Pack Tactics" action:bite`, []searchClause{
+			{fieldTrait, []string{"pack", "tactic"}, false}, {fieldAction, []string{"bite"}, true}}},
+		{"half-dragon", []searchClause{{-1, []string{"half", "dragon"}, false}}},
+		{"color:red", []searchClause{{-1, []string{"color", "red"}, false}}},
+		{`name:"unclosed phrase`, []searchClause{{fieldName, []string{"unclosed", "phrase"}, false}}},
+		{"invisibility", []searchClause{{-1, []string{"invisible"}, true}}},
+		{"trait:", nil},
+		{`"`, nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := parseQuery(tt.q); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseQuery(%q) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func testSearchMonsters() []*Monster {
+	return []*Monster{
+		{Name: "Goblin", Type: "humanoid (goblinoid)",
+			Traits:  []Trait{{Name: "Nimble Escape", Text: []string{"The goblin can take the Disengage or Hide action as a bonus action."}}},
+			Actions: []Trait{{Name: "Scimitar", Text: []string{"Melee Weapon Attack."}}}},
+		{Name: "Hobgoblin", Type: "humanoid (goblinoid)",
+			Traits: []Trait{{Name: "Martial Advantage", Text: []string{"Once per turn, the hobgoblin can deal extra damage."}}}},
+		{Name: "Wolf", Type: "beast",
+			Traits:  []Trait{{Name: "Pack Tactics", Text: []string{"The wolf has advantage on an attack roll."}}},
+			Actions: []Trait{{Name: "Bite", Text: []string{"Melee Weapon Attack."}}}},
+		{Name: "Quasit", Type: "fiend (demon)",
+			Traits:  []Trait{{Name: "Invisibility", Text: []string{"The quasit magically turns invisible."}}},
+			Actions: []Trait{{Name: "Claws (Bite in Beast Form)", Text: []string{"Melee Weapon Attack."}}}},
+		{Name: "Pack Mule", Type: "beast",
+			Traits: []Trait{{Name: "Beast of Burden", Text: []string{"The tactics of the mule are simple."}}}},
+	}
+}
+
+func searchNames(results []searchResult) []string {
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Monster.Name)
+	}
+	return names
+}
+
+func TestSearch(t *testing.T) {
+	idx := newSearchIndex(testSearchMonsters())
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		// Whole words rank above prefixes and parts of names.
+		{"goblin", []string{"Goblin", "Hobgoblin"}},
+		{"gob", []string{"Goblin", "Hobgoblin"}},
+		{"blin", []string{"Goblin", "Hobgoblin"}},
+		{"name:blin", []string{"Goblin", "Hobgoblin"}},
+		// Parts of words only match names, and not when short.
+		{"nimb", []string{"Goblin"}},
+		{"imble", nil},
+		{"ob", nil},
+		{`"pack tactics"`, []string{"Wolf"}},
+		{"pack tactics", []string{"Pack Mule", "Wolf"}},
+		{"trait:invisible", []string{"Quasit"}},
+		{"invisibility", []string{"Quasit"}},
+		{"action:bite", []string{"Quasit", "Wolf"}},
+		{"type:beast pack", []string{"Pack Mule", "Wolf"}},
+		{"type:goblinoid hobgoblin", []string{"Hobgoblin"}},
+		{"trait:", nil},
+		{"dragon", nil},
+	}
+	for _, tt := range tests {
+		if got := searchNames(idx.Search(tt.q)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestMonsterListEmptySearch(t *testing.T) {
+	dir := writeTestData(t, map[string]string{"Bestiary.xml": testCompendium})
+	es, err := NewEncounterServer(":0", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es.routes()
+	ts := httptest.NewServer(es.server)
+	defer ts.Close()
+
+	for _, search := range []string{"", "trait:", "%22", "%20"} {
+		resp, err := http.Get(ts.URL + "/api/monsters?search=" + search)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list struct {
+			Monsters []*Monster `json:"monsters"`
+			Scores   []float64  `json:"scores"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Monsters) != 2 || list.Scores != nil {
+			t.Errorf("Search %q listed %d monsters with scores %v, want 2 without scores", search, len(list.Monsters), list.Scores)
+		}
+	}
+}
+
+func TestSearchIndexCounts(t *testing.T) {
+	idx := newSearchIndex(testSearchMonsters())
+	for term, want := range map[string]int{"goblin": 1, "hobgoblin": 1, "bite": 2, "dragon": 0} {
+		docs := make(map[int]bool)
+		for _, p := range idx.postings[term] {
+			docs[p.doc] = true
+		}
+		if idx.docs[term] != want || len(docs) != want {
+			t.Errorf("%q is in %d monsters, counted %d, want %d", term, len(docs), idx.docs[term], want)
+		}
+	}
+	// Only words of names are found by their parts.
+	if got, want := idx.substringCandidates("obl"), []string{"goblin", "hobgoblin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("substringCandidates(%q) = %q, want %q", "obl", got, want)
+	}
+	if got := idx.substringCandidates("imble"); got != nil {
+		t.Errorf("substringCandidates(%q) = %q, want none", "imble", got)
+	}
+}
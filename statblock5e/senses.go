@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Senses holds the ranges, in feet, of a creature's special senses.
+type Senses struct {
+	Darkvision  int
+	Blindsight  int
+	BlindBeyond bool
+	Tremorsense int
+	Truesight   int
+	// Other holds senses that are not one of the above.
+	Other []string
+}
+
+var senseRe = regexp.MustCompile(`(?i)^(darkvision|blindsight|tremorsense|truesight)\s+(\d+)\s*(?:ft\.?|feet)(.*)$`)
+
+// ParseSenses parses text like
+// "blindsight 10 ft. (blind beyond this radius), darkvision 60 ft.".
+func ParseSenses(s string) Senses {
+	senses := Senses{}
+	for _, part := range splitList(s) {
+		if strings.HasPrefix(strings.ToLower(part), "passive") {
+			continue
+		}
+		p := senseRe.FindStringSubmatch(part)
+		if p == nil {
+			senses.Other = append(senses.Other, part)
+			continue
+		}
+		r, _ := strconv.Atoi(p[2])
+		switch strings.ToLower(p[1]) {
+		case "darkvision":
+			senses.Darkvision = r
+		case "blindsight":
+			senses.Blindsight = r
+			senses.BlindBeyond = strings.Contains(strings.ToLower(p[3]), "blind beyond")
+		case "tremorsense":
+			senses.Tremorsense = r
+		case "truesight":
+			senses.Truesight = r
+		}
+	}
+	return senses
+}
+
+// SeesInDark reports whether the creature can see without light.
+func (s Senses) SeesInDark() bool {
+	return s.Darkvision > 0 || s.Blindsight > 0 || s.Truesight > 0
+}
+
+// Short returns an abbreviated list of senses for the tracker table, e.g.
+// "DV 60, BS 10".
+func (s Senses) Short() string {
+	var l []string
+	for _, v := range []struct {
+		abbr  string
+		value int
+	}{{"DV", s.Darkvision}, {"BS", s.Blindsight}, {"TS", s.Tremorsense}, {"TrS", s.Truesight}} {
+		if v.value > 0 {
+			l = append(l, fmt.Sprintf("%s %d", v.abbr, v.value))
+		}
+	}
+	return strings.Join(l, ", ")
+}
+
+// parseSenses fills in m.SpecialSenses and m.PassivePerception. A missing
+// passive Perception is computed from the Perception skill.
+func (m *Monster) parseSenses() []error {
+	m.SpecialSenses = ParseSenses(m.Senses)
+	passive := strings.TrimSpace(m.Passive)
+	if passive == "" {
+		m.PassivePerception = 10 + m.SkillBonus("Perception")
+		return nil
+	}
+	p, err := strconv.Atoi(passive)
+	if err != nil {
+		m.PassivePerception = 10 + m.SkillBonus("Perception")
+		return []error{fmt.Errorf("Invalid passive Perception %q", passive)}
+	}
+	m.PassivePerception = p
+	return nil
+}
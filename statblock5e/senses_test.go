@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSenses(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  Senses
+		short string
+	}{
+		{"", Senses{}, ""},
+		{"darkvision 60 ft.", Senses{Darkvision: 60}, "DV 60"},
+		{"blindsight 10 ft. (blind beyond this radius), darkvision 60 ft., passive Perception 12",
+			Senses{Darkvision: 60, Blindsight: 10, BlindBeyond: true}, "DV 60, BS 10"},
+		{"Blindsight 30 feet", Senses{Blindsight: 30}, "BS 30"},
+		{"tremorsense 60 ft., truesight 120 ft.", Senses{Tremorsense: 60, Truesight: 120}, "TS 60, TrS 120"},
+		{"darkvision 60 ft., smells fear", Senses{Darkvision: 60, Other: []string{"smells fear"}}, "DV 60"},
+	}
+	for _, tt := range tests {
+		s := ParseSenses(tt.in)
+		if !reflect.DeepEqual(s, tt.want) {
+			t.Errorf("ParseSenses(%q) = %+v, want %+v", tt.in, s, tt.want)
+		}
+		if got := s.Short(); got != tt.short {
+			t.Errorf("ParseSenses(%q).Short() = %q, want %q", tt.in, got, tt.short)
+		}
+	}
+	if ParseSenses("tremorsense 30 ft.").SeesInDark() || !ParseSenses("truesight 30 ft.").SeesInDark() {
+		t.Errorf("SeesInDark wrong")
+	}
+}
+
+// sensesTestMonster returns a monster with the given skills and passive
+// Perception, parsed far enough for the tracker.
+func sensesTestMonster(name, wis, skill, passive string) *Monster {
+	m := &Monster{Name: name, Str: "10", Dex: "10", Con: "10", Int: "10", Wis: wis, Cha: "10",
+		Skill: skill, Passive: passive, HitPoints: HitPoints{4, Dice{1, 8, 0}}}
+	m.parseAbilities()
+	m.parseSkills()
+	m.parseSenses()
+	return m
+}
+
+func TestPassivePerception(t *testing.T) {
+	tests := []struct {
+		wis, skill, passive string
+		want                int
+		err                 bool
+	}{
+		{"14", "", "", 12, false},
+		{"14", "Perception +6", "", 16, false},
+		{"14", "Perception +6", "15", 15, false},
+		{"8", "", " 9 ", 9, false},
+		{"14", "Perception +4", "lots", 14, true},
+	}
+	for _, tt := range tests {
+		m := &Monster{Name: "Watcher", Wis: tt.wis, Skill: tt.skill, Passive: tt.passive}
+		m.parseAbilities()
+		m.parseSkills()
+		errs := m.parseSenses()
+		if (len(errs) > 0) != tt.err {
+			t.Errorf("Wis %s, skills %q, passive %q: errors %v", tt.wis, tt.skill, tt.passive, errs)
+		}
+		if m.PassivePerception != tt.want {
+			t.Errorf("Wis %s, skills %q, passive %q: passive Perception %d, want %d", tt.wis, tt.skill, tt.passive, m.PassivePerception, tt.want)
+		}
+	}
+}
+
+func TestTrackerSortPassive(t *testing.T) {
+	monsters := map[string]*Monster{
+		"Zombie":  sensesTestMonster("Zombie", "6", "", "8"),
+		"Scout":   sensesTestMonster("Scout", "13", "Perception +4", ""),
+		"Goblin":  sensesTestMonster("Goblin", "8", "", "9"),
+		"Watcher": sensesTestMonster("Watcher", "10", "", "15"),
+	}
+	e, err := NewEncounterFromJson(strings.NewReader(`{"Monsters": [
+		{"Name": "Zombie", "Quantity": 1}, {"Name": "Goblin", "Quantity": 2},
+		{"Name": "Scout", "Quantity": 1}, {"Name": "Watcher", "Quantity": 1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Fill(func(ref string) *Monster { return monsters[ref] })
+	if err := e.RollHitPoints(); err != nil {
+		t.Fatal(err)
+	}
+
+	order := func() []string {
+		rows, err := e.Tracker()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var l []string
+		for _, r := range rows {
+			l = append(l, fmt.Sprintf("%s %d", r.Monster.Name, r.Num))
+		}
+		return l
+	}
+	if got, want := order(), []string{"Zombie 1", "Goblin 1", "Goblin 2", "Scout 1", "Watcher 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tracker order %q, want %q", got, want)
+	}
+	e.Sort = "passive"
+	if got, want := order(), []string{"Watcher 1", "Scout 1", "Goblin 1", "Goblin 2", "Zombie 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tracker order %q, want %q", got, want)
+	}
+	e.Sort = "initiative"
+	if _, err := e.Tracker(); err == nil {
+		t.Errorf("Tracker with sort %q succeeded, want an error", e.Sort)
+	}
+}
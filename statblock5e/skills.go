@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SkillAbilities maps each skill to the ability it is based on.
+var SkillAbilities = map[string]string{
+	"Acrobatics":      "Dex",
+	"Animal Handling": "Wis",
+	"Arcana":          "Int",
+	"Athletics":       "Str",
+	"Deception":       "Cha",
+	"History":         "Int",
+	"Insight":         "Wis",
+	"Intimidation":    "Cha",
+	"Investigation":   "Int",
+	"Medicine":        "Wis",
+	"Nature":          "Int",
+	"Perception":      "Wis",
+	"Performance":     "Cha",
+	"Persuasion":      "Cha",
+	"Religion":        "Int",
+	"Sleight of Hand": "Dex",
+	"Stealth":         "Dex",
+	"Survival":        "Wis",
+}
+
+var bonusRe = regexp.MustCompile(`^(.+?)\s*([+\-–−])\s*(\d+)$`)
+
+// parseBonusList parses lists like "Dex +5, Wis +3" into name/bonus pairs.
+func parseBonusList(s string) (map[string]int, error) {
+	bonuses := make(map[string]int)
+	var bad []string
+	for _, part := range splitList(s) {
+		p := bonusRe.FindStringSubmatch(part)
+		if p == nil {
+			bad = append(bad, part)
+			continue
+		}
+		b, _ := strconv.Atoi(p[3])
+		if p[2] != "+" {
+			b = -b
+		}
+		bonuses[p[1]] = b
+	}
+	if len(bad) > 0 {
+		return bonuses, fmt.Errorf("Could not parse %q", strings.Join(bad, ", "))
+	}
+	return bonuses, nil
+}
+
+// skillName returns the canonical name for a skill, or "" if unknown.
+func skillName(s string) string {
+	for name := range SkillAbilities {
+		if strings.EqualFold(name, s) {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseSaves sets the save bonus of each ability listed in m.Save. Abilities
+// that are not listed keep their modifier.
+func (m *Monster) parseSaves() []error {
+	var errs []error
+	saves, err := parseBonusList(m.Save)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Saving throws: %s", err))
+	}
+	for name, bonus := range saves {
+		a := m.Abilities.Get(name)
+		if a == nil {
+			errs = append(errs, fmt.Errorf("Saving throws: unknown ability %q", name))
+			continue
+		}
+		a.Save = bonus
+	}
+	return errs
+}
+
+// parseSkills fills in m.Skills with a bonus for every skill, using the
+// ability modifier for skills that are not listed in m.Skill.
+func (m *Monster) parseSkills() []error {
+	var errs []error
+	m.Skills = make(map[string]int)
+	for name, ability := range SkillAbilities {
+		m.Skills[name] = m.Abilities.Get(ability).Modifier
+	}
+	skills, err := parseBonusList(m.Skill)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Skills: %s", err))
+	}
+	for s, bonus := range skills {
+		name := skillName(s)
+		if name == "" {
+			errs = append(errs, fmt.Errorf("Skills: unknown skill %q", s))
+			continue
+		}
+		m.Skills[name] = bonus
+	}
+	return errs
+}
+
+// SaveBonus returns the saving throw bonus for an ability, or 0 if the
+// ability is unknown.
+func (m *Monster) SaveBonus(ability string) int {
+	if a := m.Abilities.Get(ability); a != nil {
+		return a.Save
+	}
+	return 0
+}
+
+// SkillBonus returns the bonus for a skill check. Unknown skills fall back
+// to an ability check if name is an ability.
+func (m *Monster) SkillBonus(name string) int {
+	if b, ok := m.Skills[skillName(name)]; ok {
+		return b
+	}
+	if a := m.Abilities.Get(name); a != nil {
+		return a.Modifier
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSaves(t *testing.T) {
+	m := &Monster{Name: "Mage", Str: "9", Dex: "14", Con: "11", Int: "17", Wis: "12", Cha: "11",
+		Save: "Int +6, wis +4, Luck +1"}
+	m.parseAbilities()
+	errs := m.parseSaves()
+	if len(errs) != 1 || errs[0].Error() != `Saving throws: unknown ability "Luck"` {
+		t.Errorf("parseSaves errors %v", errs)
+	}
+	want := map[string]int{"Str": -1, "Dex": 2, "Con": 0, "Int": 6, "Wis": 4, "Cha": 0}
+	for name, bonus := range want {
+		if got := m.SaveBonus(name); got != bonus {
+			t.Errorf("SaveBonus(%q) = %d, want %d", name, got, bonus)
+		}
+	}
+	if got := m.SaveBonus("Luck"); got != 0 {
+		t.Errorf("SaveBonus(%q) = %d, want 0", "Luck", got)
+	}
+
+	m.Save = "Dex +5, Con"
+	if errs := m.parseSaves(); len(errs) != 1 || errs[0].Error() != `Saving throws: Could not parse "Con"` {
+		t.Errorf("parseSaves errors %v", errs)
+	}
+	if got := m.SaveBonus("Dex"); got != 5 {
+		t.Errorf("SaveBonus(%q) = %d, want 5", "Dex", got)
+	}
+}
+
+func TestParseSkills(t *testing.T) {
+	m := &Monster{Name: "Scout", Str: "11", Dex: "14", Con: "12", Int: "11", Wis: "13", Cha: "11",
+		Skill: "Nature +4, perception +5, Stealth –1, Cooking +3"}
+	m.parseAbilities()
+	errs := m.parseSkills()
+	if len(errs) != 1 || errs[0].Error() != `Skills: unknown skill "Cooking"` {
+		t.Errorf("parseSkills errors %v", errs)
+	}
+	if len(m.Skills) != len(SkillAbilities) {
+		t.Errorf("%d skills, want %d", len(m.Skills), len(SkillAbilities))
+	}
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"Nature", 4},
+		{"Perception", 5},
+		{"Stealth", -1},
+		{"Acrobatics", 2},
+		{"sleight of hand", 2},
+		{"Athletics", 0},
+		{"Insight", 1},
+		{"Wis", 1},
+		{"Cooking", 0},
+	}
+	for _, tt := range tests {
+		if got := m.SkillBonus(tt.name); got != tt.want {
+			t.Errorf("SkillBonus(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSkillWarnings(t *testing.T) {
+	dir := writeTestData(t, map[string]string{"Scouts.xml": `<compendium><monster><name>Scout</name>` +
+		`<str>11</str><dex>14</dex><con>12</con><int>11</int><wis>13</wis><cha>11</cha><cr>1/2</cr>` +
+		`<ac>13</ac><hp>16 (3d8+3)</hp><speed>30 ft.</speed>` +
+		`<skill>Perception +5, Cooking +3</skill></monster></compendium>`})
+	var b bytes.Buffer
+	log.SetOutput(&b)
+	defer log.SetOutput(os.Stderr)
+	c, err := LoadCompendium(filepath.Join(dir, "data", "Scouts.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `WARNING: Monster "Scout" in "Scouts": Skills: unknown skill "Cooking"`; !strings.Contains(b.String(), want) {
+		t.Errorf("Log %q, want %q", b.String(), want)
+	}
+	if len(c.Monsters) != 1 || c.Monsters[0].SkillBonus("Perception") != 5 || c.Monsters[0].SkillBonus("Stealth") != 2 {
+		t.Errorf("Monsters %+v", c.Monsters)
+	}
+}
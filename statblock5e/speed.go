@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Movement modes.
+const (
+	MoveWalk   = "walk"
+	MoveFly    = "fly"
+	MoveSwim   = "swim"
+	MoveClimb  = "climb"
+	MoveBurrow = "burrow"
+	// MoveHover is not a key of Movement; it selects creatures that fly
+	// and can hover.
+	MoveHover = "hover"
+)
+
+// Movement maps a movement mode to its speed in feet.
+type Movement map[string]int
+
+var speedRe = regexp.MustCompile(`(?i)^(?:(walk|fly|swim|climb|burrow)\s+)?(\d+)\s*(?:ft\.?|feet)(.*)$`)
+
+// ParseSpeed parses speed text like "30 ft., fly 60 ft. (hover), swim 30 ft."
+// and reports whether the creature can hover.
+func ParseSpeed(s string) (Movement, bool, error) {
+	mv := Movement{}
+	hover := false
+	var bad []string
+	for _, part := range splitList(s) {
+		p := speedRe.FindStringSubmatch(part)
+		if p == nil {
+			bad = append(bad, part)
+			continue
+		}
+		mode := strings.ToLower(p[1])
+		if mode == "" {
+			mode = MoveWalk
+		}
+		// Alternate forms like "30 ft. (40 ft. in bear form)" keep the first
+		// value for the mode.
+		if _, ok := mv[mode]; !ok {
+			mv[mode], _ = strconv.Atoi(p[2])
+		}
+		if strings.Contains(strings.ToLower(p[3]), "hover") {
+			hover = true
+		}
+	}
+	if len(bad) > 0 {
+		return mv, hover, fmt.Errorf("Could not parse speed %q", strings.Join(bad, ", "))
+	}
+	return mv, hover, nil
+}
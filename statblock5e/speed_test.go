@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseSpeed(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  Movement
+		hover bool
+		err   bool
+	}{
+		{in: "30 ft.", want: Movement{MoveWalk: 30}},
+		{in: "30 ft., fly 60 ft. (hover), swim 30 ft.", want: Movement{MoveWalk: 30, MoveFly: 60, MoveSwim: 30}, hover: true},
+		{in: "0 ft., fly 40 ft. (hover)", want: Movement{MoveWalk: 0, MoveFly: 40}, hover: true},
+		{in: "40 ft., climb 40 ft., Fly 80 ft.", want: Movement{MoveWalk: 40, MoveClimb: 40, MoveFly: 80}},
+		{in: "20 feet, burrow 10 feet", want: Movement{MoveWalk: 20, MoveBurrow: 10}},
+		{in: "30 ft. (40 ft. in bear form)", want: Movement{MoveWalk: 30}},
+		{in: "30 ft., teleport", want: Movement{MoveWalk: 30}, err: true},
+	}
+	for _, tt := range tests {
+		mv, hover, err := ParseSpeed(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseSpeed(%q) error %v, want error %v", tt.in, err, tt.err)
+		}
+		if !reflect.DeepEqual(mv, tt.want) || hover != tt.hover {
+			t.Errorf("ParseSpeed(%q) = %v, %v, want %v, %v", tt.in, mv, hover, tt.want, tt.hover)
+		}
+	}
+}
+
+func TestMonsterListMovement(t *testing.T) {
+	monster := func(name, speed string) string {
+		return "<monster><name>" + name + "</name><speed>" + speed + "</speed><cr>1</cr></monster>"
+	}
+	dir := writeTestData(t, map[string]string{"Flyers.xml": "<compendium>" +
+		monster("Goblin", "30 ft.") +
+		monster("Eagle", "10 ft., fly 60 ft.") +
+		monster("Imp", "20 ft., fly 40 ft. (hover)") +
+		monster("Ghost", "0 ft., fly 60 ft. (hover)") +
+		monster("Dragon", "40 ft., fly 80 ft.") +
+		monster("Shark", "0 ft., swim 40 ft.") +
+		"</compendium>"})
+	es, err := NewEncounterServer(":0", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es.routes()
+	ts := httptest.NewServer(es.server)
+	defer ts.Close()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"movement=fly&minspeed=60", []string{"Dragon", "Eagle", "Ghost"}},
+		{"movement=fly", []string{"Dragon", "Eagle", "Ghost", "Imp"}},
+		{"movement=hover", []string{"Ghost", "Imp"}},
+		{"movement=hover&minspeed=50", []string{"Ghost"}},
+		{"minspeed=30", []string{"Dragon", "Goblin"}},
+		{"movement=swim", []string{"Shark"}},
+	}
+	for _, tt := range tests {
+		var list struct {
+			Monsters []*Monster `json:"monsters"`
+		}
+		getJson(t, ts.URL+"/api/monsters?"+tt.query, &list)
+		var names []string
+		for _, m := range list.Monsters {
+			names = append(names, m.Name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("/api/monsters?%s = %q, want %q", tt.query, names, tt.want)
+		}
+	}
+}
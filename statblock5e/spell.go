@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Spell is a Lion's Den <spell> entry.
+type Spell struct {
+	XMLName    xml.Name `xml:"spell" json:"-"`
+	Source     string   `xml:"-"`
+	Name       string   `xml:"name"`
+	Level      int      `xml:"level"`
+	School     string   `xml:"school,omitempty"`
+	Ritual     string   `xml:"ritual,omitempty"`
+	Time       string   `xml:"time,omitempty"`
+	Range      string   `xml:"range,omitempty"`
+	Components string   `xml:"components,omitempty"`
+	Duration   string   `xml:"duration,omitempty"`
+	Classes    string   `xml:"classes,omitempty"`
+	ClassList  []string `xml:"-"`
+	Text       []string `xml:"text"`
+	Roll       []string `xml:"roll,omitempty"`
+
+	Extras []struct {
+		XMLName xml.Name
+		Attrs   []xml.Attr `xml:",any,attr" json:",omitempty"`
+		Content string     `xml:",innerxml"`
+	} `xml:",any"`
+}
+
+// spellSchools maps the Lion's Den school abbreviations to their names.
+var spellSchools = map[string]string{
+	"A":  "abjuration",
+	"C":  "conjuration",
+	"D":  "divination",
+	"EN": "enchantment",
+	"EV": "evocation",
+	"I":  "illusion",
+	"N":  "necromancy",
+	"T":  "transmutation",
+}
+
+// Parse fills in the class list.
+func (s *Spell) Parse() {
+	s.ClassList = splitList(s.Classes)
+}
+
+// SchoolName returns the school in full, e.g. "evocation" for "EV".
+func (s *Spell) SchoolName() string {
+	if name, ok := spellSchools[strings.ToUpper(s.School)]; ok {
+		return name
+	}
+	return strings.ToLower(s.School)
+}
+
+// IsRitual reports whether the spell can be cast as a ritual.
+func (s *Spell) IsRitual() bool {
+	return strings.EqualFold(s.Ritual, "YES")
+}
+
+// LevelText formats the level and school the way the books do, e.g.
+// "3rd-level evocation" or "necromancy cantrip".
+func (s *Spell) LevelText() string {
+	text := ordinal(s.Level) + "-level " + s.SchoolName()
+	if s.Level == 0 {
+		text = s.SchoolName() + " cantrip"
+	}
+	if s.IsRitual() {
+		text += " (ritual)"
+	}
+	return strings.TrimSpace(text)
+}
+
+// HasClass reports whether class can cast the spell. Lion's Den lists
+// subclasses as e.g. "Cleric (Life)", which match "Cleric" too.
+func (s *Spell) HasClass(class string) bool {
+	for _, c := range s.ClassList {
+		if strings.EqualFold(c, class) || strings.EqualFold(strings.TrimSpace(parenRe.ReplaceAllString(c, "")), class) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindSpell returns the spell called name, ignoring case, or nil.
+func (c *Compendium) FindSpell(name string) *Spell {
+	for _, s := range c.Spells {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSpells = `<?xml version="1.0" encoding="UTF-8"?>
+<compendium version="5">
+	<spell>
+		<name>Fireball</name>
+		<level>3</level>
+		<school>EV</school>
+		<time>1 action</time>
+		<range>150 feet</range>
+		<components>V, S, M (a tiny ball of bat guano and sulfur)</components>
+		<duration>Instantaneous</duration>
+		<classes>Sorcerer, Wizard, Cleric (Light)</classes>
+		<text>A bright streak flashes from your pointing finger.</text>
+		<roll>8d6</roll>
+	</spell>
+	<spell>
+		<name>Detect Magic</name>
+		<level>1</level>
+		<school>D</school>
+		<ritual>YES</ritual>
+		<classes>Bard, Cleric, Wizard</classes>
+		<text>For the duration, you sense the presence of magic within 30 feet of you.</text>
+		<foo>bar</foo>
+	</spell>
+	<spell>
+		<name>Chill Touch</name>
+		<level>0</level>
+		<school>N</school>
+		<classes>Sorcerer, Warlock, Wizard</classes>
+	</spell>
+</compendium>
+`
+
+func TestSpell(t *testing.T) {
+	tests := []struct {
+		spell     Spell
+		levelText string
+		classes   []string
+		has       []string
+		hasNot    []string
+	}{
+		{Spell{Level: 3, School: "EV", Classes: "Sorcerer, Wizard, Cleric (Light)"}, "3rd-level evocation",
+			[]string{"Sorcerer", "Wizard", "Cleric (Light)"}, []string{"wizard", "Cleric", "cleric (light)"}, []string{"Bard", "Light"}},
+		{Spell{Level: 1, School: "d", Ritual: "YES", Classes: "Bard"}, "1st-level divination (ritual)",
+			[]string{"Bard"}, []string{"Bard"}, []string{"Wizard"}},
+		{Spell{Level: 0, School: "N"}, "necromancy cantrip", nil, nil, []string{"Wizard"}},
+		{Spell{Level: 2, School: "Chronurgy", Ritual: "NO"}, "2nd-level chronurgy", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		s := tt.spell
+		s.Parse()
+		if got := s.LevelText(); got != tt.levelText {
+			t.Errorf("LevelText() of %+v = %q, want %q", tt.spell, got, tt.levelText)
+		}
+		if !reflect.DeepEqual(s.ClassList, tt.classes) {
+			t.Errorf("ClassList of %q = %q, want %q", s.Classes, s.ClassList, tt.classes)
+		}
+		for _, c := range tt.has {
+			if !s.HasClass(c) {
+				t.Errorf("HasClass(%q) of %q = false", c, s.Classes)
+			}
+		}
+		for _, c := range tt.hasNot {
+			if s.HasClass(c) {
+				t.Errorf("HasClass(%q) of %q = true", c, s.Classes)
+			}
+		}
+	}
+}
+
+func TestSpellCompendium(t *testing.T) {
+	dir := writeTestData(t, map[string]string{"Spells.xml": testSpells})
+	c, err := LoadCompendium(dir + "/data/Spells.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Spells) != 3 {
+		t.Fatalf("Loaded %d spells, want 3", len(c.Spells))
+	}
+	if s := c.FindSpell("detect magic"); s == nil || !s.IsRitual() || len(s.Extras) != 1 {
+		t.Errorf("FindSpell(%q) = %+v", "detect magic", s)
+	}
+
+	// Unknown elements are written back as they were read.
+	var b bytes.Buffer
+	if err := c.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<foo>bar</foo>", "<roll>8d6</roll>", "<ritual>YES</ritual>"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Written compendium does not have %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestSpellList(t *testing.T) {
+	dir := writeTestData(t, map[string]string{"Spells.xml": testSpells, "Bestiary.xml": testCompendium})
+	es, err := NewEncounterServer(":0", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es.routes()
+	ts := httptest.NewServer(es.server)
+	defer ts.Close()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Fireball", "Detect Magic", "Chill Touch"}},
+		{"sort=name", []string{"Chill Touch", "Detect Magic", "Fireball"}},
+		{"sort=level", []string{"Chill Touch", "Detect Magic", "Fireball"}},
+		{"search=fire", []string{"Fireball"}},
+		{"class=cleric", []string{"Fireball", "Detect Magic"}},
+		{"ritual=1", []string{"Detect Magic"}},
+		{"level=0", []string{"Chill Touch"}},
+		{"school=evocation", []string{"Fireball"}},
+		{"compendium=bestiary", nil},
+	}
+	for _, tt := range tests {
+		var list struct {
+			Spells []*Spell `json:"spells"`
+		}
+		getJson(t, ts.URL+"/api/spells?"+tt.query, &list)
+		var names []string
+		for _, s := range list.Spells {
+			names = append(names, s.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("/api/spells?%s = %q, want %q", tt.query, names, tt.want)
+		}
+	}
+
+	var s Spell
+	getJson(t, ts.URL+"/api/spells/Fireball", &s)
+	if s.Name != "Fireball" || s.Level != 3 {
+		t.Errorf("/api/spells/Fireball = %+v", s)
+	}
+	if resp, err := http.Get(ts.URL + "/api/spells/Wish"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("/api/spells/Wish: %v, %v, want 404", resp, err)
+	}
+}
+
+// getJson decodes the JSON response to a GET of url into v.
+func getJson(t *testing.T, url string, v interface{}) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Errorf("GET %s: %s", url, err)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Spellcasting is the structured form of a Spellcasting or Innate
+// Spellcasting trait.
+type Spellcasting struct {
+	Innate bool
+	// Level is the caster level, e.g. 9 for a 9th-level spellcaster.
+	Level       int
+	Ability     string
+	SaveDC      int
+	AttackBonus int
+	// Spells maps a spell level to the prepared spells of that level. Level
+	// 0 holds cantrips.
+	Spells map[int][]string
+	// Slots holds the number of slots per spell level, starting at 1st level.
+	Slots []int
+	// AtWill and PerDay hold innate spells. PerDay is keyed by the number
+	// of uses per day.
+	AtWill []string
+	PerDay map[int][]string
+}
+
+var (
+	casterLevelRe  = regexp.MustCompile(`(?i)(\d+)(?:st|nd|rd|th)-level spellcaster`)
+	castAbilityRe  = regexp.MustCompile(`(?i)spellcasting ability is (\w+)`)
+	spellDcRe      = regexp.MustCompile(`(?i)spell save DC (\d+)`)
+	spellAttackRe  = regexp.MustCompile(`(?i)([+\-–]\d+) to hit with spell attacks`)
+	cantripLineRe  = regexp.MustCompile(`(?i)^cantrips?\s*(?:\([^)]*\))?\s*:\s*(.+)$`)
+	levelLineRe    = regexp.MustCompile(`(?i)^(\d+)(?:st|nd|rd|th)[- ]level[^(:]*(?:\(([^)]*)\))?\s*:\s*(.+)$`)
+	atWillLineRe   = regexp.MustCompile(`(?i)^at will\s*:\s*(.+)$`)
+	perDayLineRe   = regexp.MustCompile(`(?i)^(\d+)/day(?:\s+each)?\s*:\s*(.+)$`)
+	leadingCountRe = regexp.MustCompile(`^(\d+)`)
+)
+
+// ParseSpellcasting parses the text of a spellcasting trait.
+func ParseSpellcasting(t Trait) *Spellcasting {
+	sc := &Spellcasting{
+		Innate: strings.Contains(strings.ToLower(t.Name), "innate"),
+		Spells: make(map[int][]string),
+		PerDay: make(map[int][]string),
+	}
+	for _, line := range t.Text {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "•*-"))
+		if p := cantripLineRe.FindStringSubmatch(line); p != nil {
+			sc.Spells[0] = splitSpells(p[1])
+			continue
+		}
+		if p := levelLineRe.FindStringSubmatch(line); p != nil {
+			level, _ := strconv.Atoi(p[1])
+			sc.Spells[level] = splitSpells(p[3])
+			if c := leadingCountRe.FindStringSubmatch(p[2]); c != nil {
+				for len(sc.Slots) < level {
+					sc.Slots = append(sc.Slots, 0)
+				}
+				sc.Slots[level-1], _ = strconv.Atoi(c[1])
+			}
+			continue
+		}
+		if p := atWillLineRe.FindStringSubmatch(line); p != nil {
+			sc.AtWill = splitSpells(p[1])
+			continue
+		}
+		if p := perDayLineRe.FindStringSubmatch(line); p != nil {
+			n, _ := strconv.Atoi(p[1])
+			sc.PerDay[n] = append(sc.PerDay[n], splitSpells(p[2])...)
+			continue
+		}
+		if p := casterLevelRe.FindStringSubmatch(line); p != nil {
+			sc.Level, _ = strconv.Atoi(p[1])
+		}
+		if p := castAbilityRe.FindStringSubmatch(line); p != nil {
+			sc.Ability = abilityName(p[1])
+		}
+		if p := spellDcRe.FindStringSubmatch(line); p != nil {
+			sc.SaveDC, _ = strconv.Atoi(p[1])
+		}
+		if p := spellAttackRe.FindStringSubmatch(line); p != nil {
+			sc.AttackBonus, _ = strconv.Atoi(strings.Replace(p[1], "–", "-", 1))
+		}
+	}
+	return sc
+}
+
+// splitSpells splits a list of spell names, dropping the asterisks the books
+// use to mark spells cast before combat.
+func splitSpells(s string) []string {
+	var spells []string
+	for _, spell := range splitList(s) {
+		if spell = strings.TrimSpace(strings.Trim(spell, "*")); spell != "" {
+			spells = append(spells, spell)
+		}
+	}
+	return spells
+}
+
+// ParseSlots parses the Lion's Den slots field, e.g. "4,3,3,3,1".
+func ParseSlots(s string) ([]int, error) {
+	var slots []int
+	for _, n := range strings.Split(s, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid spell slots %q", s)
+		}
+		slots = append(slots, i)
+	}
+	return slots, nil
+}
+
+// SlotsText formats the slots for tracking on paper, e.g. "1st ○○○○ 2nd ○○○".
+func (sc *Spellcasting) SlotsText() string {
+	if sc == nil {
+		return ""
+	}
+	var parts []string
+	for i, n := range sc.Slots {
+		if n > 0 {
+			parts = append(parts, ordinal(i+1)+" "+strings.Repeat("○", n))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func ordinal(n int) string {
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+		return strconv.Itoa(n) + "th"
+	case n%10 == 1:
+		return strconv.Itoa(n) + "st"
+	case n%10 == 2:
+		return strconv.Itoa(n) + "nd"
+	case n%10 == 3:
+		return strconv.Itoa(n) + "rd"
+	}
+	return strconv.Itoa(n) + "th"
+}
+
+// parseSpellcasting builds m.Spellcasting and m.InnateSpellcasting from the
+// spellcasting traits and the spells and slots fields.
+func (m *Monster) parseSpellcasting() []error {
+	var errs []error
+	m.Spellcasting, m.InnateSpellcasting = nil, nil
+	for _, t := range m.Traits {
+		if !strings.Contains(strings.ToLower(t.Name), "spellcasting") {
+			continue
+		}
+		sc := ParseSpellcasting(t)
+		if sc.Innate {
+			m.InnateSpellcasting = sc
+		} else {
+			m.Spellcasting = sc
+		}
+	}
+	if strings.TrimSpace(m.Slots) != "" {
+		slots, err := ParseSlots(m.Slots)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			if m.Spellcasting == nil {
+				m.Spellcasting = &Spellcasting{Spells: make(map[int][]string), PerDay: make(map[int][]string)}
+			}
+			m.Spellcasting.Slots = slots
+		}
+	}
+	m.SpellList = splitSpells(m.Spells)
+	return errs
+}
+
+// spellFieldsFromText fills the Lion's Den spells and slots fields from the
+// spellcasting traits, for formats that only have the prose.
+func (m *Monster) spellFieldsFromText() {
+	var spells []string
+	var slots []int
+	for _, t := range m.Traits {
+		if !strings.Contains(strings.ToLower(t.Name), "spellcasting") {
+			continue
+		}
+		sc := ParseSpellcasting(t)
+		for level := 0; level <= 9; level++ {
+			spells = append(spells, sc.Spells[level]...)
+		}
+		spells = append(spells, sc.AtWill...)
+		for n := 9; n > 0; n-- {
+			spells = append(spells, sc.PerDay[n]...)
+		}
+		if len(sc.Slots) > 0 {
+			slots = sc.Slots
+		}
+	}
+	m.Spells = strings.Join(spells, ", ")
+	var slotText []string
+	for _, n := range slots {
+		slotText = append(slotText, strconv.Itoa(n))
+	}
+	m.Slots = strings.Join(slotText, ",")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSpellcasting(t *testing.T) {
+	mage := ParseSpellcasting(Trait{Name: "Spellcasting", Text: []string{
+		"The mage is a 9th-level spellcaster. Its spellcasting ability is Intelligence (spell save DC 14, +6 to hit with spell attacks). The mage has the following wizard spells prepared:",
+		"Cantrips (at will): fire bolt, light, mage hand, prestidigitation",
+		"• 1st level (4 slots): detect magic, mage armor, magic missile, shield",
+		"2nd level (3 slots): misty step, suggestion",
+		"3rd level (3 slots): counterspell, fireball, fly",
+		"5th level (1 slot): cone of cold*",
+	}})
+	want := &Spellcasting{
+		Level: 9, Ability: "Int", SaveDC: 14, AttackBonus: 6,
+		Spells: map[int][]string{
+			0: {"fire bolt", "light", "mage hand", "prestidigitation"},
+			1: {"detect magic", "mage armor", "magic missile", "shield"},
+			2: {"misty step", "suggestion"},
+			3: {"counterspell", "fireball", "fly"},
+			5: {"cone of cold"},
+		},
+		Slots:  []int{4, 3, 3, 0, 1},
+		PerDay: map[int][]string{},
+	}
+	if !reflect.DeepEqual(mage, want) {
+		t.Errorf("ParseSpellcasting(mage) =\n%+v\nwant\n%+v", mage, want)
+	}
+	if got, want := mage.SlotsText(), "1st ○○○○ 2nd ○○○ 3rd ○○○ 5th ○"; got != want {
+		t.Errorf("SlotsText() = %q, want %q", got, want)
+	}
+
+	innate := ParseSpellcasting(Trait{Name: "Innate Spellcasting (Psionics)", Text: []string{
+		"The drow's innate spellcasting ability is Charisma (spell save DC 11). It can innately cast the following spells, requiring no material components:",
+		"At will: dancing lights",
+		"1/day each: darkness, faerie fire",
+		"3/day: levitate (self only)",
+	}})
+	want = &Spellcasting{
+		Innate: true, Ability: "Cha", SaveDC: 11,
+		Spells: map[int][]string{},
+		AtWill: []string{"dancing lights"},
+		PerDay: map[int][]string{1: {"darkness", "faerie fire"}, 3: {"levitate (self only)"}},
+	}
+	if !reflect.DeepEqual(innate, want) {
+		t.Errorf("ParseSpellcasting(innate) =\n%+v\nwant\n%+v", innate, want)
+	}
+}
+
+func TestParseSlots(t *testing.T) {
+	if slots, err := ParseSlots("4, 3,3,3,1"); err != nil || !reflect.DeepEqual(slots, []int{4, 3, 3, 3, 1}) {
+		t.Errorf("ParseSlots = %v, %v", slots, err)
+	}
+	if slots, err := ParseSlots("4,three"); err == nil {
+		t.Errorf("ParseSlots(%q) = %v, want an error", "4,three", slots)
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 102: "102nd"} {
+		if got := ordinal(n); got != want {
+			t.Errorf("ordinal(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestSpellFieldsFromText(t *testing.T) {
+	m := &Monster{Traits: []Trait{
+		{Name: "Spellcasting", Text: []string{
+			"Cantrips (at will): light",
+			"1st level (2 slots): shield",
+			"2nd level (1 slot): misty step",
+		}},
+		{Name: "Innate Spellcasting", Text: []string{"At will: mage hand", "1/day: fly"}},
+	}}
+	m.spellFieldsFromText()
+	if m.Spells != "light, shield, misty step, mage hand, fly" || m.Slots != "2,1" {
+		t.Errorf("spellFieldsFromText gave spells %q, slots %q", m.Spells, m.Slots)
+	}
+	if errs := m.parseSpellcasting(); len(errs) > 0 || m.InnateSpellcasting == nil ||
+		!reflect.DeepEqual(m.Spellcasting.Slots, []int{2, 1}) || len(m.SpellList) != 5 {
+		t.Errorf("parseSpellcasting gave %+v, %+v, %q, errors %v", m.Spellcasting, m.InnateSpellcasting, m.SpellList, errs)
+	}
+}
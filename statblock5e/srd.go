@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file reads the SRD monster JSON used by the 5e-database project
+// (5e-SRD-Monsters.json) and by Open5e, either as an API dump
+// ({"results": [...]}) or as a Django fixture ([{"fields": {...}}]). Like the
+// 5etools loader, monsters are rendered into the Lion's Den text fields and
+// then parsed.
+
+type srdMonster map[string]interface{}
+
+// IsSrdFile reports whether a JSON file looks like an SRD or Open5e dump
+// rather than a 5etools bestiary.
+func IsSrdFile(path string) bool {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		return true
+	}
+	var f map[string]json.RawMessage
+	if json.Unmarshal(b, &f) != nil {
+		return false
+	}
+	_, ok := f["results"]
+	return ok
+}
+
+// LoadSrdCompendium loads a 5e-database or Open5e monster file.
+func LoadSrdCompendium(path string) (*Compendium, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load compendium from file %q: %s", path, err)
+	}
+	var monsters []srdMonster
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		err = json.Unmarshal(b, &monsters)
+	} else {
+		var f struct {
+			Results []srdMonster `json:"results"`
+		}
+		err = json.Unmarshal(b, &f)
+		monsters = f.Results
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse SRD file %q: %s", path, err)
+	}
+
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	c := &Compendium{Name: name, File: path}
+	for _, sm := range monsters {
+		if fields, ok := sm["fields"].(map[string]interface{}); ok {
+			sm = unwrapSrdFixture(fields)
+		}
+		m := srdToMonster(sm)
+		if m.Name == "" {
+			continue
+		}
+		m.Source = name
+		for _, err := range m.Parse() {
+			log.Printf("WARNING: Monster %q in %q: %s", m.Name, name, err)
+		}
+		c.Monsters = append(c.Monsters, m)
+	}
+	return c, nil
+}
+
+// unwrapSrdFixture decodes the "*_json" string fields of an Open5e fixture
+// into the keys the API uses, e.g. "speed_json" into "speed".
+func unwrapSrdFixture(fields map[string]interface{}) srdMonster {
+	sm := srdMonster{}
+	for k, v := range fields {
+		if strings.HasSuffix(k, "_json") {
+			var decoded interface{}
+			if s, ok := v.(string); ok && json.Unmarshal([]byte(s), &decoded) == nil {
+				sm[strings.TrimSuffix(k, "_json")] = decoded
+			}
+			continue
+		}
+		sm[k] = v
+	}
+	return sm
+}
+
+var srdAbilities = []string{"strength", "dexterity", "constitution", "intelligence", "wisdom", "charisma"}
+
+func srdToMonster(sm srdMonster) *Monster {
+	m := &Monster{
+		Name:        jsonString(sm["name"]),
+		Alignment:   jsonString(sm["alignment"]),
+		Ac:          srdAc(sm),
+		Hp:          srdHp(sm),
+		Speed:       srdSpeed(sm["speed"]),
+		Str:         jsonString(sm["strength"]),
+		Dex:         jsonString(sm["dexterity"]),
+		Con:         jsonString(sm["constitution"]),
+		Int:         jsonString(sm["intelligence"]),
+		Wis:         jsonString(sm["wisdom"]),
+		Cha:         jsonString(sm["charisma"]),
+		Languages:   jsonString(sm["languages"]),
+		Cr:          srdCr(sm["challenge_rating"]),
+		Description: jsonString(sm["desc"]),
+		Environment: strings.Join(srdNames(sm["environments"]), ", "),
+	}
+	if size := jsonString(sm["size"]); size != "" {
+		m.Size = size[0:1]
+	}
+	m.Type = jsonString(sm["type"])
+	if sub := jsonString(sm["subtype"]); sub != "" {
+		m.Type += " (" + sub + ")"
+	}
+	if doc := jsonString(sm["document__title"]); doc != "" {
+		m.Type += ", " + doc
+	}
+
+	m.Save, m.Skill = srdProficiencies(sm)
+	m.Vulnerabilities = srdDamage(sm["damage_vulnerabilities"])
+	m.Resistances = srdDamage(sm["damage_resistances"])
+	m.DamageImmunity = srdDamage(sm["damage_immunities"])
+	m.ConditionImmunity = strings.Join(srdNames(sm["condition_immunities"]), ", ")
+	m.Senses, m.Passive = srdSenses(sm)
+
+	var spells []string
+	for _, a := range srdEntries(sm["special_abilities"]) {
+		m.Traits = append(m.Traits, srdTrait(a))
+		if sc, ok := a["spellcasting"].(map[string]interface{}); ok {
+			for _, s := range jsonList(sc["spells"]) {
+				if sp, ok := s.(map[string]interface{}); ok {
+					spells = append(spells, jsonString(sp["name"]))
+				}
+			}
+			m.Slots = srdSlots(sc["slots"])
+		}
+	}
+	m.Spells = strings.Join(spells, ", ")
+	for _, a := range srdEntries(sm["actions"]) {
+		m.Actions = append(m.Actions, srdTrait(a))
+	}
+	for _, a := range srdEntries(sm["reactions"]) {
+		m.Reactions = append(m.Reactions, srdTrait(a))
+	}
+	if legendary := srdEntries(sm["legendary_actions"]); len(legendary) > 0 {
+		intro := jsonString(sm["legendary_desc"])
+		if intro == "" {
+			intro = legendaryIntro(m.Name, 3)
+		}
+		m.Legendary = append(m.Legendary, Trait{Name: "Legendary Actions", Text: []string{intro}})
+		for _, a := range legendary {
+			m.Legendary = append(m.Legendary, srdTrait(a))
+		}
+	}
+	return m
+}
+
+// srdNames returns the names from a list of strings or {"name": ...} objects.
+func srdNames(v interface{}) []string {
+	var names []string
+	for _, n := range jsonList(v) {
+		switch t := n.(type) {
+		case string:
+			names = append(names, splitList(t)...)
+		case map[string]interface{}:
+			names = append(names, strings.ToLower(jsonString(t["name"])))
+		}
+	}
+	return names
+}
+
+func srdAc(sm srdMonster) string {
+	switch ac := sm["armor_class"].(type) {
+	case float64:
+		s := jsonString(ac)
+		if desc := jsonString(sm["armor_desc"]); desc != "" {
+			s += " (" + desc + ")"
+		}
+		return s
+	case []interface{}:
+		var s string
+		var sources []string
+		for i, a := range ac {
+			e, _ := a.(map[string]interface{})
+			var src []string
+			switch jsonString(e["type"]) {
+			case "natural":
+				src = append(src, "natural armor")
+			case "armor":
+				src = append(src, srdNames(e["armor"])...)
+			case "spell":
+				if sp, ok := e["spell"].(map[string]interface{}); ok {
+					src = append(src, strings.ToLower(jsonString(sp["name"])))
+				}
+			case "condition":
+				src = append(src, jsonString(e["desc"]))
+			}
+			if i == 0 {
+				s = jsonString(e["value"])
+				sources = append(sources, src...)
+			} else {
+				sources = append(sources, jsonString(e["value"])+" with "+strings.Join(src, ", "))
+			}
+		}
+		if len(sources) > 0 {
+			s += " (" + strings.Join(sources, ", ") + ")"
+		}
+		return s
+	}
+	return ""
+}
+
+func srdHp(sm srdMonster) string {
+	s := jsonString(sm["hit_points"])
+	dice := jsonString(sm["hit_points_roll"])
+	if dice == "" {
+		dice = jsonString(sm["hit_dice"])
+	}
+	if dice != "" {
+		s += " (" + strings.Replace(dice, " ", "", -1) + ")"
+	}
+	return s
+}
+
+func srdSpeed(v interface{}) string {
+	sp, _ := v.(map[string]interface{})
+	var parts []string
+	for _, mode := range []string{MoveWalk, MoveBurrow, MoveClimb, MoveFly, MoveSwim} {
+		s := jsonString(sp[mode])
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "ft") {
+			s += " ft."
+		}
+		if mode == MoveFly && sp["hover"] == true {
+			s += " (hover)"
+		}
+		if mode != MoveWalk {
+			s = mode + " " + s
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", ")
+}
+
+var srdFractionalCr = map[float64]string{0.125: "1/8", 0.25: "1/4", 0.5: "1/2"}
+
+func srdCr(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		if s, ok := srdFractionalCr[f]; ok {
+			return s
+		}
+	}
+	return jsonString(v)
+}
+
+// srdProficiencies returns the Lion's Den save and skill text from either
+// the 5e-database "proficiencies" list or the Open5e "*_save" and "skills"
+// fields.
+func srdProficiencies(sm srdMonster) (string, string) {
+	saves := make(map[string]int)
+	skills := make(map[string]int)
+	for _, p := range jsonList(sm["proficiencies"]) {
+		e, _ := p.(map[string]interface{})
+		prof, _ := e["proficiency"].(map[string]interface{})
+		index := jsonString(prof["index"])
+		value := int(jsonNumber(e["value"]))
+		switch {
+		case strings.HasPrefix(index, "saving-throw-"):
+			saves[abilityName(strings.TrimPrefix(index, "saving-throw-"))] = value
+		case strings.HasPrefix(index, "skill-"):
+			skills[skillName(strings.Replace(strings.TrimPrefix(index, "skill-"), "-", " ", -1))] = value
+		}
+	}
+	for _, a := range srdAbilities {
+		if v, ok := sm[a+"_save"].(float64); ok {
+			saves[abilityName(a)] = int(v)
+		}
+	}
+	if sk, ok := sm["skills"].(map[string]interface{}); ok {
+		for k, v := range sk {
+			skills[skillName(strings.Replace(k, "_", " ", -1))] = int(jsonNumber(v))
+		}
+	}
+
+	var saveText, skillText []string
+	for _, a := range AbilityNames {
+		if v, ok := saves[a]; ok {
+			saveText = append(saveText, a+" "+formatBonus(v))
+		}
+	}
+	var names []string
+	for name := range skills {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		skillText = append(skillText, name+" "+formatBonus(skills[name]))
+	}
+	return strings.Join(saveText, ", "), strings.Join(skillText, ", ")
+}
+
+// srdDamage renders a damage list. 5e-database uses a list of strings,
+// Open5e a single string.
+func srdDamage(v interface{}) string {
+	var groups []string
+	for _, d := range jsonList(v) {
+		if s := strings.TrimSpace(jsonString(d)); s != "" {
+			groups = append(groups, s)
+		}
+	}
+	// Plain damage types go first as one group, the qualified ones follow.
+	var plain, qualified []string
+	for _, g := range groups {
+		if strings.Contains(g, " ") {
+			qualified = append(qualified, g)
+		} else {
+			plain = append(plain, g)
+		}
+	}
+	if len(plain) > 0 {
+		qualified = append([]string{strings.Join(plain, ", ")}, qualified...)
+	}
+	return strings.Join(qualified, "; ")
+}
+
+// srdSenses returns the senses without passive Perception, and passive
+// Perception on its own.
+func srdSenses(sm srdMonster) (string, string) {
+	var senses []string
+	passive := ""
+	switch s := sm["senses"].(type) {
+	case string:
+		for _, part := range splitList(s) {
+			if strings.HasPrefix(strings.ToLower(part), "passive perception") {
+				passive = strings.TrimSpace(part[len("passive perception"):])
+				continue
+			}
+			senses = append(senses, part)
+		}
+	case map[string]interface{}:
+		var keys []string
+		for k := range s {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "passive_perception" {
+				passive = jsonString(s[k])
+				continue
+			}
+			senses = append(senses, k+" "+jsonString(s[k]))
+		}
+	}
+	if passive == "" && sm["perception"] != nil {
+		passive = strconv.Itoa(10 + int(jsonNumber(sm["perception"])))
+	}
+	return strings.Join(senses, ", "), passive
+}
+
+// srdUsage renders a 5e-database usage object in the form the trait name
+// parser reads, e.g. "Recharge 5–6" or "3/Day".
+func srdUsage(v interface{}) string {
+	u, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	switch jsonString(u["type"]) {
+	case "recharge on roll":
+		return fmt.Sprintf("Recharge %s–6", jsonString(u["min_value"]))
+	case "per day":
+		return jsonString(u["times"]) + "/Day"
+	case "recharge after rest":
+		var rests []string
+		for _, r := range jsonList(u["rest_types"]) {
+			rests = append(rests, titleCase(jsonString(r)))
+		}
+		return "Recharges after a " + strings.Join(rests, " or ") + " Rest"
+	}
+	return ""
+}
+
+// srdEntries returns the objects of an ability list. Open5e uses an empty
+// string for missing lists.
+func srdEntries(v interface{}) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, e := range jsonList(v) {
+		if m, ok := e.(map[string]interface{}); ok {
+			entries = append(entries, m)
+		}
+	}
+	return entries
+}
+
+func srdTrait(e map[string]interface{}) Trait {
+	t := Trait{Name: jsonString(e["name"])}
+	if u := srdUsage(e["usage"]); u != "" && !strings.Contains(t.Name, "(") {
+		t.Name += " (" + u + ")"
+	}
+	for _, line := range strings.Split(jsonString(e["desc"]), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			t.Text = append(t.Text, line)
+		}
+	}
+
+	// Attacks are named without the usage, as attacksFromText does.
+	name := strings.TrimSpace(parenRe.ReplaceAllString(t.Name, ""))
+	hit := ""
+	if b, ok := e["attack_bonus"].(float64); ok {
+		hit = formatBonus(int(b))
+	}
+	// 5e-database lists each damage roll with its type.
+	for _, d := range jsonList(e["damage"]) {
+		dm, _ := d.(map[string]interface{})
+		if dice := jsonString(dm["damage_dice"]); dice != "" {
+			t.Attack = append(t.Attack, name+"|"+hit+"|"+strings.Replace(dice, " ", "", -1))
+			hit = ""
+		}
+	}
+	// Open5e gives the dice and bonus separately.
+	if dice, ok := e["damage_dice"].(string); ok && dice != "" {
+		if b := int(jsonNumber(e["damage_bonus"])); b != 0 {
+			dice += formatBonus(b)
+		}
+		t.Attack = append(t.Attack, name+"|"+hit+"|"+dice)
+	}
+	return t
+}
+
+func srdSlots(v interface{}) string {
+	sl, _ := v.(map[string]interface{})
+	var slots []string
+	for level := 1; level <= 9; level++ {
+		n, ok := sl[strconv.Itoa(level)]
+		if !ok {
+			break
+		}
+		slots = append(slots, jsonString(n))
+	}
+	return strings.Join(slots, ",")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const srdAboleth = `[
+{"index":"aboleth","name":"Aboleth","size":"Large","type":"aberration","alignment":"lawful evil","armor_class":[{"type":"natural","value":17}],
+"hit_points":135,"hit_dice":"18d10","hit_points_roll":"18d10+36","speed":{"walk":"10 ft.","swim":"40 ft."},
+"strength":21,"dexterity":9,"constitution":15,"intelligence":18,"wisdom":15,"charisma":18,
+"proficiencies":[{"value":6,"proficiency":{"index":"saving-throw-con","name":"Saving Throw: CON"}},{"value":8,"proficiency":{"index":"saving-throw-int","name":"Saving Throw: INT"}},{"value":12,"proficiency":{"index":"skill-history","name":"Skill: History"}},{"value":10,"proficiency":{"index":"skill-perception","name":"Skill: Perception"}}],
+"damage_vulnerabilities":[],"damage_resistances":["bludgeoning, piercing, and slashing from nonmagical weapons"],"damage_immunities":["poison"],"condition_immunities":[{"index":"poisoned","name":"Poisoned"}],
+"senses":{"darkvision":"120 ft.","passive_perception":20},"languages":"Deep Speech, telepathy 120 ft.","challenge_rating":10,"xp":5900,
+"special_abilities":[{"name":"Amphibious","desc":"The aboleth can breathe air and water."},
+ {"name":"Spellcasting","desc":"The aboleth is a 5th-level spellcaster. Its spellcasting ability is Intelligence (spell save DC 16, +8 to hit with spell attacks).\n\n- Cantrips (at will): light\n- 1st level (4 slots): shield\n- 2nd level (2 slots): hold person","spellcasting":{"slots":{"1":4,"2":2},"spells":[{"name":"Light"},{"name":"Shield"},{"name":"Hold Person"}]}}],
+"actions":[{"name":"Tentacle","desc":"Melee Weapon Attack: +9 to hit, reach 10 ft., one target. Hit: 12 (2d6 + 5) bludgeoning damage.","attack_bonus":9,"damage":[{"damage_type":{"index":"bludgeoning","name":"Bludgeoning"},"damage_dice":"2d6+5"}]},
+ {"name":"Breath","desc":"Each creature takes 22 (4d10) acid damage.","usage":{"type":"recharge on roll","dice":"1d6","min_value":5},"damage":[{"damage_type":{"index":"acid"},"damage_dice":"4d10"}]},
+ {"name":"Enslave","desc":"...","usage":{"type":"per day","times":3}}],
+"legendary_actions":[{"name":"Detect","desc":"The aboleth makes a Wisdom (Perception) check."},{"name":"Psychic Drain (Costs 2 Actions)","desc":"Drain."}]}
+]`
+
+const open5eGoblin = `{"count": 1, "results": [{"slug": "goblin", "name": "Goblin", "size": "Small", "type": "humanoid", "subtype": "goblinoid",
+	"alignment": "neutral evil", "armor_class": 15, "armor_desc": "leather armor, shield", "hit_points": 7, "hit_dice": "2d6",
+	"speed": {"walk": 30}, "strength": 8, "dexterity": 14, "constitution": 10, "intelligence": 10, "wisdom": 8, "charisma": 8,
+	"dexterity_save": null, "perception": null, "skills": {"stealth": 6},
+	"damage_vulnerabilities": "", "damage_resistances": "", "damage_immunities": "", "condition_immunities": "",
+	"senses": "darkvision 60 ft., passive Perception 9", "languages": "Common, Goblin", "challenge_rating": "1/4",
+	"actions": [{"name": "Scimitar", "desc": "Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 5 (1d6 + 2) slashing damage.", "attack_bonus": 4, "damage_dice": "1d6", "damage_bonus": 2}],
+	"reactions": "", "legendary_actions": "",
+	"special_abilities": [{"name": "Nimble Escape", "desc": "The goblin can take the Disengage or Hide action as a bonus action on each of its turns."}],
+	"environments": ["Forest", "Grassland"]}]}`
+
+const open5eFixtureWolf = `[{"model": "api.monster", "pk": "wolf", "fields": {"name": "Wolf", "size": "Medium", "type": "beast", "subtype": "",
+	"alignment": "unaligned", "armor_class": 13, "armor_desc": "natural armor", "hit_points": 11, "hit_dice": "2d8+2",
+	"speed_json": "{\"walk\": 40}", "strength": 12, "dexterity": 15, "constitution": 12, "intelligence": 3, "wisdom": 12, "charisma": 6,
+	"skills_json": "{\"perception\": 3, \"stealth\": 4}", "senses": "passive Perception 13", "languages": "", "challenge_rating": "1/4",
+	"actions_json": "[{\"name\": \"Bite\", \"desc\": \"Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 7 (2d4 + 2) piercing damage.\", \"attack_bonus\": 4, \"damage_dice\": \"2d4\", \"damage_bonus\": 2}]"}}]`
+
+func TestLoadSrdCompendium(t *testing.T) {
+	dir := writeTestData(t, map[string]string{
+		"5e-SRD-Monsters.json": srdAboleth,
+		"open5e.json":          open5eGoblin,
+		"fixture.json":         open5eFixtureWolf,
+		"bestiary.json":        fiveEToolsGoblin,
+	})
+	tests := []struct {
+		file    string
+		want    Monster
+		attacks []string
+	}{
+		{
+			file: "5e-SRD-Monsters.json",
+			want: Monster{Name: "Aboleth", Size: "L", Type: "aberration", Ac: "17 (natural armor)", Hp: "135 (18d10+36)",
+				Speed: "10 ft., swim 40 ft.", Save: "Con +6, Int +8", Skill: "History +12, Perception +10",
+				Resistances: "bludgeoning, piercing, and slashing from nonmagical weapons", DamageImmunity: "poison",
+				ConditionImmunity: "poisoned", Senses: "darkvision 120 ft.", Passive: "20", Cr: "10",
+				Slots: "4,2", Spells: "Light, Shield, Hold Person"},
+			attacks: []string{"Tentacle|+9|2d6+5", "Breath||4d10"},
+		},
+		{
+			file: "open5e.json",
+			want: Monster{Name: "Goblin", Size: "S", Type: "humanoid (goblinoid)",
+				Ac: "15 (leather armor, shield)", Hp: "7 (2d6)", Speed: "30 ft.", Skill: "Stealth +6",
+				Senses: "darkvision 60 ft.", Passive: "9", Cr: "1/4"},
+			attacks: []string{"Scimitar|+4|1d6+2"},
+		},
+		{
+			file: "fixture.json",
+			want: Monster{Name: "Wolf", Size: "M", Type: "beast", Ac: "13 (natural armor)", Hp: "11 (2d8+2)",
+				Speed: "40 ft.", Skill: "Perception +3, Stealth +4", Passive: "13", Cr: "1/4"},
+			attacks: []string{"Bite|+4|2d4+2"},
+		},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "data", tt.file)
+		if !IsSrdFile(path) {
+			t.Errorf("IsSrdFile(%q) = false", tt.file)
+		}
+		c, err := LoadSrdCompendium(path)
+		if err != nil {
+			t.Errorf("LoadSrdCompendium(%q): %s", tt.file, err)
+			continue
+		}
+		if len(c.Monsters) != 1 {
+			t.Errorf("LoadSrdCompendium(%q) loaded %d monsters, want 1", tt.file, len(c.Monsters))
+			continue
+		}
+		m := c.Monsters[0]
+		got := Monster{Name: m.Name, Size: m.Size, Type: m.Type, Ac: m.Ac, Hp: m.Hp, Speed: m.Speed,
+			Save: m.Save, Skill: m.Skill, Resistances: m.Resistances, DamageImmunity: m.DamageImmunity,
+			ConditionImmunity: m.ConditionImmunity, Senses: m.Senses, Passive: m.Passive, Cr: m.Cr,
+			Slots: m.Slots, Spells: m.Spells}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: loaded\n%+v\nwant\n%+v", tt.file, got, tt.want)
+		}
+		var attacks []string
+		for _, a := range m.Actions {
+			attacks = append(attacks, a.Attack...)
+		}
+		if !reflect.DeepEqual(attacks, tt.attacks) {
+			t.Errorf("%s: attacks %q, want %q", tt.file, attacks, tt.attacks)
+		}
+	}
+	if IsSrdFile(filepath.Join(dir, "data", "bestiary.json")) {
+		t.Errorf("IsSrdFile of a 5etools bestiary = true")
+	}
+}
+
+func TestSrdUsage(t *testing.T) {
+	dir := writeTestData(t, map[string]string{"srd.json": srdAboleth})
+	c, err := LoadSrdCompendium(filepath.Join(dir, "data", "srd.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := c.Monsters[0]
+	var headings []string
+	for _, list := range [][]Trait{m.Actions, m.Legendary} {
+		for _, tr := range list {
+			headings = append(headings, tr.Heading())
+		}
+	}
+	want := []string{"Tentacle", "Breath (Recharge 5–6)", "Enslave (3/Day)", "Legendary Actions", "Detect", "Psychic Drain (Costs 2 Actions)"}
+	if !reflect.DeepEqual(headings, want) {
+		t.Errorf("Headings %q, want %q", headings, want)
+	}
+	if got := srdUsage(map[string]interface{}{"type": "recharge after rest", "rest_types": []interface{}{"short", "long"}}); got != "Recharges after a Short or Long Rest" {
+		t.Errorf("srdUsage(rest) = %q", got)
+	}
+}
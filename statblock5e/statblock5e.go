@@ -2,27 +2,43 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"time"
 )
 
 var verbose bool
 func main() {
-	var check, encounter, addr, root string
+	var reload time.Duration
+	var check, parse, output, encounter, hp, sortBy, format, monster, addr, root string
 	flag.BoolVar(&verbose, "v", false, "Verbose mode")
 	flag.StringVar(&check, "c", "", "Check the XML file for unparsed XML")
+	flag.StringVar(&parse, "p", "", "Parse a plain-text stat block from this file (- for standard input) and print it as Lion's Den XML")
+	flag.StringVar(&output, "o", "", "Write the compendium checked with -c, or the stat block parsed with -p, to this file as Lion's Den XML")
 	flag.StringVar(&encounter, "e", "", "Encounter YAML file")
+	flag.StringVar(&hp, "hp", "", "Hit points for each monster in the encounter: average, roll or max")
+	flag.StringVar(&sortBy, "sort", "", "Order of the encounter tracker: passive (highest passive Perception first) or empty for the encounter's order")
+	flag.StringVar(&format, "format", "html", "Output format for -e, -c and -p: html, foundry (Foundry VTT dnd5e actors), homebrewery (markdown) or csv")
+	flag.StringVar(&monster, "m", "", "With -c and -format foundry, homebrewery or csv, export only this monster")
 	flag.StringVar(&addr, "s", "", "Start server on specified address")
 	flag.StringVar(&root, "d", "", "root directory that contains data and html subdirs")
+	flag.DurationVar(&reload, "reload", 10*time.Second, "How often the server checks the data directory for changes, 0 to only reload on SIGHUP or POST /api/reload")
 
 	flag.Parse()
 
+	if format != "html" && format != "foundry" && format != "homebrewery" && format != "csv" {
+		log.Printf("ERROR: Unknown format %q", format)
+		os.Exit(1)
+	}
+
 	if addr != "" {
 		es, err := NewEncounterServer(addr, root)
 		if err != nil {
 			log.Printf("ERROR: Could not create server: %s", err)
 			os.Exit(1)
 		}
+		es.ReloadInterval = reload
 		err = es.Serve()
 		if err != nil {
 			log.Printf("ERROR: Could not start http server: %s", err)
@@ -46,7 +62,27 @@ func main() {
 			log.Printf("ERROR: Could not load encounter: %s", err)
 			os.Exit(1)
 		}
-		err = e.Print(os.Stdout)
+		if hp != "" {
+			e.Hp = hp
+		}
+		if sortBy != "" {
+			e.Sort = sortBy
+		}
+		err = e.RollHitPoints()
+		if err != nil {
+			log.Printf("ERROR: Could not set hit points: %s", err)
+			os.Exit(1)
+		}
+		switch format {
+		case "foundry":
+			err = e.WriteFoundryPack(os.Stdout)
+		case "homebrewery":
+			err = e.WriteHomebrewery(os.Stdout)
+		case "csv":
+			err = e.WriteCsv(os.Stdout)
+		default:
+			err = e.Print(os.Stdout)
+		}
 		if err != nil {
 			log.Printf("ERROR: Could not print encounter: %s", err)
 			os.Exit(1)
@@ -54,6 +90,36 @@ func main() {
 		return
 	}
 
+	if parse != "" {
+		m, warnings, err := ReadTextStatBlock(parse)
+		if err != nil {
+			log.Printf("ERROR: Could not parse stat block: %s", err)
+			os.Exit(1)
+		}
+		for _, w := range warnings {
+			log.Printf("WARNING: %s", w)
+		}
+		c := &Compendium{Name: m.Name, Monsters: []*Monster{m}}
+		switch {
+		case format != "html":
+			err = writeMonsters(c, "", format)
+		case output == "":
+			err = c.Write(os.Stdout)
+		}
+		if err != nil {
+			log.Printf("ERROR: Could not print stat block: %s", err)
+			os.Exit(1)
+		}
+		if output != "" {
+			err = c.Save(output)
+			if err != nil {
+				log.Printf("ERROR: Could not save compendium: %s", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
 	if check != "" {
 		c, err := LoadCompendium(check)
 		if err != nil {
@@ -62,6 +128,20 @@ func main() {
 		}
 
 		checkXml(c)
+		if format != "html" {
+			err = writeMonsters(c, monster, format)
+			if err != nil {
+				log.Printf("ERROR: Could not export monsters: %s", err)
+				os.Exit(1)
+			}
+		}
+		if output != "" {
+			err = c.Save(output)
+			if err != nil {
+				log.Printf("ERROR: Could not save compendium: %s", err)
+				os.Exit(1)
+			}
+		}
 		return
 	}
 
@@ -75,5 +155,41 @@ func checkXml(c *Compendium) {
 			log.Printf("Monster %q has unparsed data: %v", m.Name, m.Extras)
 		}
 	}
+	for _, s := range c.Spells {
+		if len(s.Extras) > 0 {
+			log.Printf("Spell %q has unparsed data: %v", s.Name, s.Extras)
+		}
+	}
+	for _, it := range c.Items {
+		if len(it.Extras) > 0 {
+			log.Printf("Item %q has unparsed data: %v", it.Name, it.Extras)
+		}
+	}
 }
 
+
+// writeMonsters prints the whole compendium, or just the named monster, as
+// a Foundry VTT compendium pack (a single actor for one monster), as
+// Homebrewery markdown or as CSV.
+func writeMonsters(c *Compendium, name, format string) error {
+	// The Foundry ids are built from the monster IDs.
+	c.setIds()
+	monsters := c.Monsters
+	if name != "" {
+		m := c.FindMonster(name)
+		if m == nil {
+			return fmt.Errorf("Could not find monster %q", name)
+		}
+		if format == "foundry" {
+			return m.WriteFoundryActor(os.Stdout)
+		}
+		monsters = []*Monster{m}
+	}
+	switch format {
+	case "homebrewery":
+		return WriteHomebrewery(os.Stdout, monsters)
+	case "csv":
+		return WriteCsv(os.Stdout, monsters)
+	}
+	return WriteFoundryPack(os.Stdout, monsters)
+}
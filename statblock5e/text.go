@@ -0,0 +1,376 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// This file parses stat blocks copied as plain text, typically out of a
+// PDF, where most of the layout is lost:
+//
+//	Goblin Small humanoid (goblinoid), neutral evil Armor Class 15
+//	(leather armor, shield) Hit Points 7 (2d6) Speed 30 ft. STR DEX ...
+//
+// The properties are found by their labels, and traits by the
+// "Name." that starts them. Sections and traits are collected with the
+// statBlockBuilder that the markdown parser uses too.
+
+// statBlockBuilder collects the properties, sections and traits of stat
+// blocks into Lion's Den text fields.
+type statBlockBuilder struct {
+	monsters []*Monster
+	warnings []string
+
+	m       *Monster
+	section string
+	trait   *Trait
+}
+
+// statBlockSections maps section headings to where their entries go.
+var statBlockSections = map[string]string{
+	"actions":           "action",
+	"bonus actions":     "bonus",
+	"reactions":         "reaction",
+	"legendary actions": "legendary",
+	"lair actions":      "legendary",
+	"regional effects":  "legendary",
+}
+
+func (p *statBlockBuilder) warn(format string, args ...interface{}) {
+	name := ""
+	if p.m != nil {
+		name = p.m.Name
+	}
+	p.warnings = append(p.warnings, fmt.Sprintf("Monster %q: ", name)+fmt.Sprintf(format, args...))
+}
+
+func (p *statBlockBuilder) finish() {
+	if p.m == nil {
+		return
+	}
+	for _, traits := range [][]Trait{p.m.Traits, p.m.Actions, p.m.Reactions, p.m.Legendary} {
+		for i := range traits {
+			traits[i].attacksFromText()
+		}
+	}
+	p.m.spellFieldsFromText()
+	p.monsters = append(p.monsters, p.m)
+	p.m = nil
+}
+
+func (p *statBlockBuilder) subtitle(s string) {
+	parts := splitList(s)
+	if len(parts) == 0 {
+		return
+	}
+	p.m.Alignment = strings.Join(parts[1:], ", ")
+	t := parts[0]
+	if i := strings.Index(t, " "); i > 0 && len(t[:i]) > 1 {
+		if size := sizeLetter(t[:i]); size != "" {
+			p.m.Size, t = size, t[i+1:]
+		}
+	}
+	p.m.Type = t
+}
+
+func (p *statBlockBuilder) property(name, value string) {
+	m := p.m
+	switch strings.ToLower(name) {
+	case "armor class":
+		m.Ac = value
+	case "hit points":
+		m.Hp = value
+	case "speed":
+		m.Speed = value
+	case "saving throws":
+		m.Save = value
+	case "skills":
+		m.Skill = value
+	case "damage vulnerabilities":
+		m.Vulnerabilities = value
+	case "damage resistances":
+		m.Resistances = value
+	case "damage immunities":
+		m.DamageImmunity = value
+	case "condition immunities":
+		m.ConditionImmunity = value
+	case "senses":
+		var senses []string
+		for _, s := range splitList(value) {
+			if strings.HasPrefix(strings.ToLower(s), "passive perception") {
+				m.Passive = strings.TrimSpace(s[len("passive perception"):])
+				continue
+			}
+			senses = append(senses, s)
+		}
+		m.Senses = strings.Join(senses, ", ")
+	case "languages":
+		m.Languages = value
+	case "challenge":
+		if f := strings.Fields(value); len(f) > 0 {
+			m.Cr = f[0]
+		}
+	case "proficiency bonus":
+		// Derived from the challenge rating.
+	default:
+		p.warn("Unknown property %q", name)
+	}
+}
+
+func (p *statBlockBuilder) startSection(heading string) {
+	section, ok := statBlockSections[strings.ToLower(heading)]
+	if !ok {
+		p.warn("Unknown section %q", heading)
+		section = "action"
+	}
+	p.section, p.trait = section, nil
+	if section == "legendary" {
+		// The heading becomes a Lion's Den section header that holds the
+		// intro text.
+		p.m.Legendary = append(p.m.Legendary, Trait{Name: heading})
+		p.trait = &p.m.Legendary[len(p.m.Legendary)-1]
+	}
+}
+
+func (p *statBlockBuilder) addTrait(name, text string) {
+	t := Trait{Name: name}
+	if text != "" {
+		t.Text = []string{text}
+	}
+	var list *[]Trait
+	switch p.section {
+	case "":
+		list = &p.m.Traits
+	case "action":
+		list = &p.m.Actions
+	case "bonus":
+		t.Name += " (Bonus Action)"
+		list = &p.m.Actions
+	case "reaction":
+		list = &p.m.Reactions
+	case "legendary":
+		list = &p.m.Legendary
+	}
+	*list = append(*list, t)
+	p.trait = &(*list)[len(*list)-1]
+}
+
+var (
+	textLabelRe       = regexp.MustCompile(`\b(Armor Class|Hit Points|Speed|STR|Saving Throws|Skills|Damage Vulnerabilities|Damage Resistances|Damage Immunities|Condition Immunities|Senses|Languages|Challenge)\b`)
+	textChallengeRe   = regexp.MustCompile(`^\s*(\d+(?:/\d+)?)\s*\(\s*([\d,]+)\s*XP\s*\)`)
+	textProficiencyRe = regexp.MustCompile(`^\s*Proficiency Bonus\s*[+-]\s*\d+`)
+	textScoreRe       = regexp.MustCompile(`(\d+)\s*\(\s*[+-]?\s*\d+\s*\)`)
+	textSizeRe        = regexp.MustCompile(`^(.*?)\s*\b((?:Tiny|Small|Medium|Large|Huge|Gargantuan)\b.*)$`)
+	textHeadingRe     = regexp.MustCompile(`^(Bonus Actions|Legendary Actions|Lair Actions|Regional Effects|Reactions|Actions)\b\s*`)
+	textTraitRe       = regexp.MustCompile(`^([A-Z][^\s.:]*(?: +(?:[A-Z(/][^\s.:]*|\d[^\s.:]*|of|the|and|or|a|an|in|on|to|with|from|for|at|by)){0,7})\.\s+`)
+	textBoundaryRe    = regexp.MustCompile(`\.\s+|\n| (?:Bonus Actions|Legendary Actions|Lair Actions|Regional Effects|Reactions|Actions) `)
+	textSpellLineRe   = regexp.MustCompile(`\s((?:Cantrips?|At will|\d+/day(?: each)?|\d+(?:st|nd|rd|th)[- ]level)\b[^:.]*:)`)
+	textHyphenRe      = regexp.MustCompile(`(\w)-\n([a-z])`)
+	textCleaner       = strings.NewReplacer("ﬁ", "fi", "ﬂ", "fl", "ﬀ", "ff", "ﬃ", "ffi", "ﬄ", "ffl",
+		"­", "", "−", "-", "’", "'", "‘", "'", "“", "\"", "”", "\"", "\r", "", "\t", " ")
+)
+
+// ParseTextStatBlock parses a stat block copied as plain text. It returns
+// the parsed monster and a warning for each part that was not recognized.
+func ParseTextStatBlock(text string) (*Monster, []string) {
+	text = textHyphenRe.ReplaceAllString(textCleaner.Replace(text), "$1$2")
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.Join(strings.Fields(l), " "); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	s := strings.Join(lines, "\n")
+
+	p := &statBlockBuilder{m: &Monster{}}
+	labels := textLabelRe.FindAllStringSubmatchIndex(s, -1)
+	if len(labels) == 0 {
+		p.m.Name = strings.Join(strings.Fields(s), " ")
+		p.warn("No stat block properties found")
+		p.finish()
+		return p.monsters[0], p.warnings
+	}
+	p.header(s[:labels[0][0]])
+
+	// Each property runs up to the next label. The traits start after the
+	// challenge rating.
+	traits := -1
+	seen := make(map[string]bool)
+	for i, l := range labels {
+		name := s[l[2]:l[3]]
+		end := len(s)
+		if i+1 < len(labels) {
+			end = labels[i+1][0]
+		}
+		if name == "Challenge" {
+			c := textChallengeRe.FindStringSubmatchIndex(s[l[1]:])
+			if c == nil {
+				p.warn("Invalid challenge rating %q", firstLine(s[l[1]:]))
+				traits = l[1] + len(firstLine(s[l[1]:]))
+				break
+			}
+			p.property(name, s[l[1]+c[2]:l[1]+c[3]])
+			traits = l[1] + c[1]
+			if pb := textProficiencyRe.FindStringIndex(s[traits:]); pb != nil {
+				traits += pb[1]
+			}
+			break
+		}
+		if seen[name] {
+			p.warn("Ignoring repeated %q", name)
+			continue
+		}
+		seen[name] = true
+		value := strings.Join(strings.Fields(s[l[1]:end]), " ")
+		if name == "STR" {
+			p.abilities(value)
+			continue
+		}
+		p.property(name, value)
+	}
+	if traits < 0 {
+		p.warn("No challenge rating found")
+		last := labels[len(labels)-1]
+		traits = last[1] + len(firstLine(s[last[1]:]))
+	}
+	p.traits(strings.TrimSpace(s[traits:]))
+
+	for i := range p.m.Traits {
+		if strings.Contains(strings.ToLower(p.m.Traits[i].Name), "spellcasting") {
+			splitSpellLines(&p.m.Traits[i])
+		}
+	}
+	// Some books print the name in capitals.
+	if p.m.Name == strings.ToUpper(p.m.Name) {
+		p.m.Name = titleCase(strings.ToLower(p.m.Name))
+	}
+	m := p.m
+	p.finish()
+	for _, err := range m.Parse() {
+		p.warnings = append(p.warnings, fmt.Sprintf("Monster %q: %s", m.Name, err))
+	}
+	return m, p.warnings
+}
+
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// header reads the name and the size, type and alignment line.
+func (p *statBlockBuilder) header(s string) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > 1 {
+		if m := textSizeRe.FindStringSubmatch(lines[0]); m == nil || m[1] != "" {
+			p.m.Name = lines[0]
+			p.subtitle(strings.Join(lines[1:], " "))
+			return
+		}
+	}
+	joined := strings.Join(lines, " ")
+	m := textSizeRe.FindStringSubmatch(joined)
+	if m == nil {
+		p.m.Name = joined
+		p.warn("No size, type and alignment found")
+		return
+	}
+	p.m.Name = m[1]
+	if p.m.Name == "" {
+		p.warn("No name found")
+	}
+	p.subtitle(m[2])
+}
+
+func (p *statBlockBuilder) abilities(s string) {
+	scores := textScoreRe.FindAllStringSubmatch(s, -1)
+	if len(scores) < len(AbilityNames) {
+		p.warn("Invalid ability scores %q", s)
+		return
+	}
+	p.m.Str, p.m.Dex, p.m.Con = scores[0][1], scores[1][1], scores[2][1]
+	p.m.Int, p.m.Wis, p.m.Cha = scores[3][1], scores[4][1], scores[5][1]
+}
+
+// traits splits the rest of the stat block at section headings and trait
+// names. Headings may start any line, or follow a spell list when a trait
+// name comes right after them; trait names only start a sentence.
+func (p *statBlockBuilder) traits(s string) {
+	start := 0
+	flush := func(end int) {
+		text := strings.Join(strings.Fields(s[start:end]), " ")
+		if text == "" {
+			return
+		}
+		if p.trait == nil {
+			p.warn("Unrecognized text %q", text)
+			return
+		}
+		p.trait.Text = append(p.trait.Text, text)
+	}
+	name := func(at int) int {
+		if t := textTraitRe.FindStringSubmatch(s[at:]); t != nil {
+			p.addTrait(t[1], "")
+			return at + len(t[0])
+		}
+		return at
+	}
+
+	start = name(0)
+	for _, b := range textBoundaryRe.FindAllStringIndex(s, -1) {
+		at := b[1]
+		if s[b[0]] == ' ' {
+			// An inline heading, which the match includes.
+			at = b[0] + 1
+			if !textTraitRe.MatchString(s[b[1]:]) {
+				continue
+			}
+		}
+		if at < start {
+			continue
+		}
+		if h := textHeadingRe.FindStringSubmatch(s[at:]); h != nil {
+			flush(at)
+			p.startSection(strings.TrimSpace(h[1]))
+			start = name(at + len(h[0]))
+			continue
+		}
+		if s[b[0]] == '.' && textTraitRe.MatchString(s[at:]) {
+			flush(at)
+			start = name(at)
+		}
+	}
+	flush(len(s))
+}
+
+// splitSpellLines puts each spell level of a spellcasting trait on its own
+// line, as ParseSpellcasting expects.
+func splitSpellLines(t *Trait) {
+	text := textSpellLineRe.ReplaceAllString(strings.Join(t.Text, " "), "\n$1")
+	t.Text = strings.Split(text, "\n")
+}
+
+// ReadTextStatBlock parses the plain-text stat block in path, or on the
+// standard input if path is "-".
+func ReadTextStatBlock(path string) (*Monster, []string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Could not open stat block file %q: %s", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not read stat block: %s", err)
+	}
+	m, warnings := ParseTextStatBlock(string(b))
+	return m, warnings, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const textMage = `MAGE
+Medium humanoid (any race), any alignment
+Armor Class 12 (15 with mage armor)
+Hit Points 40 (9d8)
+Speed 30 ft.
+STR DEX CON INT WIS CHA
+9 (−1) 14 (+2) 11 (+0) 17 (+3) 12 (+1) 11 (+0)
+Saving Throws Int +6, Wis +4
+Skills Arcana +6, History +6
+Senses passive Perception 11
+Languages any four languages
+Challenge 6 (2,300 XP) Proficiency Bonus +3
+Spellcasting. The mage is a 9th-level spellcaster. Its spell-
+casting ability is Intelligence (spell save DC 14, +6 to hit
+with spell attacks). The mage has the following wizard spells
+prepared:
+Cantrips (at will): fire bolt, light, mage hand, prestidigitation
+1st level (4 slots): detect magic, mage armor, magic missile, shield
+2nd level (3 slots): misty step, suggestion
+3rd level (3 slots): counterspell, fireball, fly
+4th level (3 slots): greater invisibility, ice storm
+5th level (1 slot): cone of cold
+Actions
+Dagger. Melee or Ranged Weapon Attack: +5 to hit, reach 5 ft.
+or range 20/60 ft., one target. Hit: 4 (1d4 + 2) piercing
+damage.
+`
+
+const textGoblin = `Goblin Small humanoid (goblinoid), neutral evil Armor Class 15 (leather armor, shield) Hit Points 7 (2d6) Speed 30 ft. STR DEX CON INT WIS CHA 8 (−1) 14 (+2) 10 (+0) 10 (+0) 8 (−1) 8 (−1) Skills Stealth +6 Senses darkvision 60 ft., passive Perception 9 Languages Common, Goblin Challenge 1/4 (50 XP) Nimble Escape. The goblin can take the Disengage or Hide action as a bonus action on each of its turns. Actions Scimitar. Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 5 (1d6 + 2) slashing damage. Shortbow. Ranged Weapon Attack: +4 to hit, range 80/320 ft., one target. Hit: 5 (1d6 + 2) piercing damage.`
+
+const textDragon = `Adult Red Dragon Huge dragon, chaotic evil Armor Class 19 (natural armor) Hit Points 256 (19d12 + 133) Speed 40 ft., climb 40 ft., fly 80 ft. STR 27 (+8) DEX 10 (+0) CON 25 (+7) INT 16 (+3) WIS 13 (+1) CHA 21 (+5) Saving Throws Dex +6, Con +13, Wis +7, Cha +11 Skills Perception +13, Stealth +6 Damage Immunities fire Senses blindsight 60 ft., darkvision 120 ft., passive Perception 23 Languages Common, Draconic Challenge 17 (18,000 XP) Legendary Resistance (3/Day). If the dragon fails a saving throw, it can choose to succeed instead. Actions Multiattack. The dragon can use its Frightful Presence. It then makes three attacks: one with its bite and two with its claws. Fire Breath (Recharge 5–6). The dragon exhales fire in a 60-foot cone. Each creature in that area must make a DC 21 Dexterity saving throw, taking 63 (18d6) fire damage on a failed save, or half as much damage on a successful one. Legendary Actions The dragon can take 3 legendary actions, choosing from the options below. Detect. The dragon makes a Wisdom (Perception) check. Wing Attack (Costs 2 Actions). The dragon beats its wings.`
+
+func traitNames(list []Trait) []string {
+	var names []string
+	for _, t := range list {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func TestParseTextStatBlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		want      Monster
+		traits    []string
+		actions   []string
+		legendary []string
+		reactions []string
+		slots     []int
+	}{
+		{
+			name: "lines",
+			text: textMage,
+			want: Monster{Name: "Mage", Size: "M", Type: "humanoid (any race)", Alignment: "any alignment",
+				Ac: "12 (15 with mage armor)", Hp: "40 (9d8)", Speed: "30 ft.",
+				Str: "9", Dex: "14", Con: "11", Int: "17", Wis: "12", Cha: "11",
+				Save: "Int +6, Wis +4", Skill: "Arcana +6, History +6", Passive: "11",
+				Languages: "any four languages", Cr: "6"},
+			traits:  []string{"Spellcasting"},
+			actions: []string{"Dagger"},
+			slots:   []int{4, 3, 3, 3, 1},
+		},
+		{
+			name: "one line",
+			text: textGoblin,
+			want: Monster{Name: "Goblin", Size: "S", Type: "humanoid (goblinoid)", Alignment: "neutral evil",
+				Ac: "15 (leather armor, shield)", Hp: "7 (2d6)", Speed: "30 ft.",
+				Str: "8", Dex: "14", Con: "10", Int: "10", Wis: "8", Cha: "8",
+				Skill: "Stealth +6", Senses: "darkvision 60 ft.", Passive: "9",
+				Languages: "Common, Goblin", Cr: "1/4"},
+			traits:  []string{"Nimble Escape"},
+			actions: []string{"Scimitar", "Shortbow"},
+		},
+		{
+			name: "legendary",
+			text: textDragon,
+			want: Monster{Name: "Adult Red Dragon", Size: "H", Type: "dragon", Alignment: "chaotic evil",
+				Ac: "19 (natural armor)", Hp: "256 (19d12 + 133)", Speed: "40 ft., climb 40 ft., fly 80 ft.",
+				Str: "27", Dex: "10", Con: "25", Int: "16", Wis: "13", Cha: "21",
+				Save: "Dex +6, Con +13, Wis +7, Cha +11", Skill: "Perception +13, Stealth +6",
+				DamageImmunity: "fire", Senses: "blindsight 60 ft., darkvision 120 ft.", Passive: "23",
+				Languages: "Common, Draconic", Cr: "17"},
+			traits:    []string{"Legendary Resistance (3/Day)"},
+			actions:   []string{"Multiattack", "Fire Breath (Recharge 5–6)"},
+			legendary: []string{"Legendary Actions", "Detect", "Wing Attack (Costs 2 Actions)"},
+		},
+	}
+	for _, tt := range tests {
+		m, warnings := ParseTextStatBlock(tt.text)
+		if len(warnings) > 0 {
+			t.Errorf("%s: warnings %q", tt.name, warnings)
+		}
+		got := Monster{Name: m.Name, Size: m.Size, Type: m.Type, Alignment: m.Alignment,
+			Ac: m.Ac, Hp: m.Hp, Speed: m.Speed,
+			Str: m.Str, Dex: m.Dex, Con: m.Con, Int: m.Int, Wis: m.Wis, Cha: m.Cha,
+			Save: m.Save, Skill: m.Skill, DamageImmunity: m.DamageImmunity,
+			Senses: m.Senses, Passive: m.Passive, Languages: m.Languages, Cr: m.Cr}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parsed\n%+v\nwant\n%+v", tt.name, got, tt.want)
+		}
+		for _, c := range []struct {
+			section   string
+			got, want []string
+		}{
+			{"traits", traitNames(m.Traits), tt.traits},
+			{"actions", traitNames(m.Actions), tt.actions},
+			{"legendary", traitNames(m.Legendary), tt.legendary},
+			{"reactions", traitNames(m.Reactions), tt.reactions},
+		} {
+			if !reflect.DeepEqual(c.got, c.want) {
+				t.Errorf("%s: %s %q, want %q", tt.name, c.section, c.got, c.want)
+			}
+		}
+		if tt.slots != nil && (m.Spellcasting == nil || !reflect.DeepEqual(m.Spellcasting.Slots, tt.slots)) {
+			t.Errorf("%s: spellcasting %+v, want slots %v", tt.name, m.Spellcasting, tt.slots)
+		}
+	}
+}
+
+func TestParseTextStatBlockJoinsLines(t *testing.T) {
+	m, _ := ParseTextStatBlock(textMage)
+	if got, want := m.Traits[0].Text[0], "The mage is a 9th-level spellcaster. Its spellcasting ability is Intelligence"; !strings.HasPrefix(got, want) {
+		t.Errorf("Spellcasting text %q, want it to start with %q", got, want)
+	}
+	if got, want := m.Actions[0].Text[0], "Melee or Ranged Weapon Attack: +5 to hit, reach 5 ft. or range 20/60 ft., one target. Hit: 4 (1d4 + 2) piercing damage."; got != want {
+		t.Errorf("Dagger text %q, want %q", got, want)
+	}
+	if len(m.Actions[0].Attacks) != 1 || m.Actions[0].Attacks[0].ToHit == nil || *m.Actions[0].Attacks[0].ToHit != 5 {
+		t.Errorf("Dagger attacks %+v", m.Actions[0].Attacks)
+	}
+}
+
+func TestParseTextStatBlockWarnings(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Just some prose about goblins.", "No stat block properties found"},
+		{"Goblin Small humanoid, neutral evil Armor Class 15 Hit Points 7 (2d6) Speed 30 ft.", "No challenge rating found"},
+	}
+	for _, tt := range tests {
+		_, warnings := ParseTextStatBlock(tt.text)
+		if !strings.Contains(strings.Join(warnings, "\n"), tt.want) {
+			t.Errorf("ParseTextStatBlock(%q) warnings %q, want %q", tt.text, warnings, tt.want)
+		}
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"sort"
 	"strings"
 	"text/template"
 	"path/filepath"
@@ -15,14 +18,24 @@ import (
 )
 
 type Encounter struct {
-	Name string `yaml:name`
-	Source string `yaml:source`
+	Name string `yaml:"name"`
+	Source string `yaml:"source"`
+	// Hp selects how starting hit points are set for each copy of a
+	// monster: "average" (the default), "roll" or "max".
+	Hp string `yaml:"hp"`
+	// Sort orders the rows of the printed tracker: "" keeps the order of
+	// the encounter, "passive" puts the highest passive Perception first.
+	Sort string `yaml:"sort"`
 	Monsters []*struct {
-		Source string `yaml:source`
-		Name string `yaml:name`
-		Quantity int `yaml:quantity`
+		Source string `yaml:"source"`
+		// Id is the monster ID. Encounters made before IDs existed give the
+		// "Name (Compendium)" key, or with a source the name, instead.
+		Id string `yaml:"id"`
+		Name string `yaml:"name"`
+		Quantity int `yaml:"quantity"`
 		Monster *Monster
-	} `yaml:monsters`
+		Hp []int `yaml:"-" json:"-"`
+	} `yaml:"monsters"`
 }
 
 func NewEncounterFromJson(r io.Reader) (*Encounter, error) {
@@ -57,12 +70,18 @@ func NewEncounterFromYaml(r io.Reader) (*Encounter, error) {
 	return e, nil
 }
 
-func (e *Encounter) Fill(monsters map[string]*Monster) {
+// Fill looks up the monsters of the encounter with find, by ID or else by
+// name.
+func (e *Encounter) Fill(find func(ref string) *Monster) {
 	for _, m := range e.Monsters {
-		if mm, ok := monsters[m.Name]; ok {
+		ref := m.Id
+		if ref == "" {
+			ref = m.Name
+		}
+		if mm := find(ref); mm != nil {
 			m.Monster = mm
 		} else {
-			log.Printf("Monster %q not found.", m.Name)
+			log.Printf("Monster %q not found.", ref)
 		}
 	}
 }
@@ -77,6 +96,7 @@ func (e *Encounter) Load() error {
 			return err
 		}
 
+		c.setIds()
 		sources[e.Source] = c
 	}
 
@@ -93,8 +113,16 @@ func (e *Encounter) Load() error {
 			if err != nil {
 				return err
 			}
+			c.setIds()
 			sources[s] = c
 		}
+		if m.Id != "" {
+			m.Monster = sources[s].FindMonsterById(m.Id)
+			if m.Monster == nil {
+				return fmt.Errorf("Could not find %q in %q.", m.Id, s)
+			}
+			continue
+		}
 		m.Monster = sources[s].FindMonster(m.Name)
 		if m.Monster == nil {
 			return fmt.Errorf("Could not find %q in %q.", m.Name, s)
@@ -103,14 +131,57 @@ func (e *Encounter) Load() error {
 	return nil
 }
 
+// RollHitPoints sets the starting hit points for every copy of each monster
+// according to e.Hp. It must be called after Fill or Load.
+func (e *Encounter) RollHitPoints() error {
+	for _, m := range e.Monsters {
+		if m.Monster == nil {
+			continue
+		}
+		if m.Quantity < 0 {
+			return fmt.Errorf("Invalid quantity %d for %q.", m.Quantity, m.Monster.Name)
+		}
+		m.Hp = make([]int, m.Quantity)
+		for i := range m.Hp {
+			hp, err := m.Monster.HitPoints.Get(e.Hp)
+			if err != nil {
+				return err
+			}
+			m.Hp[i] = hp
+		}
+	}
+	return nil
+}
+
 type Compendium struct {
 	XMLName xml.Name `xml:"compendium" json:"-"`
-	File string
-	Name string
+	Version string `xml:"version,attr,omitempty" json:"-"`
+	File string `xml:"-"`
+	Name string `xml:"-"`
+	// Slug starts the IDs of the monsters; see setIds.
+	Slug string `xml:"-" json:"-"`
 	Monsters []*Monster `xml:"monster"`
+	Spells []*Spell `xml:"spell"`
+	Items []*Item `xml:"item"`
 }
 
+// LoadCompendium loads a Lion's Den XML compendium. If path ends in ".json"
+// it loads a 5etools bestiary or an SRD monster file instead, if it ends in
+// ".md" the Homebrewery stat blocks in it and if it ends in ".csv" the
+// spreadsheet rows in it.
 func LoadCompendium(path string) (*Compendium, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if IsSrdFile(path) {
+			return LoadSrdCompendium(path)
+		}
+		return LoadFiveEToolsCompendium(path)
+	case ".md":
+		return LoadHomebreweryCompendium(path)
+	case ".csv":
+		return LoadCsvCompendium(path)
+	}
+
 	b, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("Could not load compendium from file %q: %s", path, err)
@@ -128,11 +199,60 @@ func LoadCompendium(path string) (*Compendium, error) {
 
 	for _, m := range c.Monsters {
 		m.Source = name
+		for _, err := range m.Parse() {
+			log.Printf("WARNING: Monster %q in %q: %s", m.Name, name, err)
+		}
+	}
+	for _, sp := range c.Spells {
+		sp.Source = name
+		sp.Parse()
+	}
+	for _, it := range c.Items {
+		it.Source = name
+		for _, err := range it.Parse() {
+			log.Printf("WARNING: Item %q in %q: %s", it.Name, name, err)
+		}
 	}
 	return c, nil
 }
 
-func (e *Encounter) Print(w io.Writer) error {
+// Save writes the compendium to path as Lion's Den XML.
+func (c *Compendium) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Could not save compendium to file %q: %s", path, err)
+	}
+	err = c.Write(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes the compendium as Lion's Den XML. Elements that were not
+// parsed when loading are written back from Monster.Extras.
+func (c *Compendium) Write(w io.Writer) error {
+	// Encode a copy; c may be shared with requests being served.
+	out := *c
+	if out.Version == "" {
+		out.Version = "5"
+	}
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	err = enc.Encode(&out)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func pageTemplate() (*template.Template, error) {
 	tmpl := template.New("page")
 	tmpl.Funcs(template.FuncMap{"add": func(i, j int) int { return i+j }})
 	tmpl.Funcs(template.FuncMap{"breakrow": func(i, j int) bool { return (i+1) %j == 0 }})
@@ -147,19 +267,73 @@ func (e *Encounter) Print(w io.Writer) error {
 		}
 		return a
 		}})
-	tmpl, err := tmpl.Parse(page)
+	return tmpl.Parse(page)
+}
+
+// TrackerRow is one copy of a monster in the printed tracker.
+type TrackerRow struct {
+	Monster *Monster
+	Num int
+	Hp int
+}
+
+// Tracker returns a row for every copy of each monster, ordered by e.Sort.
+// It must be called after RollHitPoints.
+func (e *Encounter) Tracker() ([]TrackerRow, error) {
+	var rows []TrackerRow
+	for _, m := range e.Monsters {
+		if m.Monster == nil {
+			continue
+		}
+		for i, hp := range m.Hp {
+			rows = append(rows, TrackerRow{m.Monster, i+1, hp})
+		}
+	}
+	switch e.Sort {
+	case "":
+	case "passive":
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].Monster.PassivePerception > rows[j].Monster.PassivePerception })
+	default:
+		return nil, fmt.Errorf("Unknown tracker sort order %q, must be \"passive\" or empty", e.Sort)
+	}
+	return rows, nil
+}
+
+func (e *Encounter) Print(w io.Writer) error {
+	if _, err := e.Tracker(); err != nil {
+		return err
+	}
+	tmpl, err := pageTemplate()
 	if err != nil { return err }
 	err = tmpl.Execute(w, e)
 	if err != nil { return err }
 	return nil
 }
 
+// Print writes a page with just the stat block of the monster.
+func (m *Monster) Print(w io.Writer) error {
+	tmpl, err := pageTemplate()
+	if err != nil { return err }
+	return tmpl.ExecuteTemplate(w, "MONSTERPAGE", m)
+}
+
 
 type Trait struct {
 	XMLName xml.Name `json:"-"`
 	Name string `xml:"name"`
+	DisplayName string `xml:"-"`
 	Text []string `xml:"text"`
 	Attack []string `xml:"attack"`
+	Attacks []Attack `xml:"-"`
+	DamageTypes []string `xml:"-"`
+
+	// RechargeMin is the lowest d6 roll that recharges the trait.
+	RechargeMin int `xml:"-"`
+	RechargeRest string `xml:"-"`
+	Uses int `xml:"-"`
+	UsesPer string `xml:"-"`
+	UsesEach bool `xml:"-"`
+	LegendaryCost int `xml:"-"`
 }
 
 func (t Trait) FormattedText() []string {
@@ -172,49 +346,137 @@ func (t Trait) FormattedText() []string {
 
 type Monster struct {
 	XMLName xml.Name `xml:"monster" json:"-"`
-	Source string
+	Id string `xml:"-"`
+	Source string `xml:"-"`
+	// Compendium is the name of the compendium in the server's library,
+	// which with Name makes the "Name (Compendium)" key.
+	Compendium string `xml:"-"`
 	Name string `xml:"name"`
-	Size string `xml:"size"`
-	Type string `xml:"type"`
-	Alignment string `xml:"alignment"`
-	Ac string `xml:"ac"`
-	Hp string `xml:"hp"`
-	Speed string `xml:"speed"`
-	Str string `xml:"str"`
-	Dex string `xml:"dex"`
-	Con string `xml:"con"`
-	Int string `xml:"int"`
-	Wis string `xml:"wis"`
-	Cha string `xml:"cha"`
-	Save string `xml:"save"`
-	Skill string `xml:"skill"`
-	Vulnerabilities string `xml:"vulnerable"`
-	Resistances string `xml:"resist"`
-	DamageImmunity string `xml:"immune"`
-	ConditionImmunity string `xml:"conditionImmune"`
-	Senses string `xml:"senses"`
-	Passive string `xml:"passive"`
-	Languages string `xml:"languages"`
-	Cr string `xml:"cr"`
-
-	Traits []Trait `xml:"trait"`
-	Actions []Trait `xml:"action"`
-	Reactions []Trait `xml:"reaction"`
-	Legendary []Trait `xml:"legendary"`
-
-	Spells string `xml:"spells"` // included in text
-	Slots string `xml:"slots"` // included in text
-
-	Description string `xml:"description"`
+	Size string `xml:"size,omitempty"`
+	Type string `xml:"type,omitempty"`
+	CreatureType string `xml:"-"`
+	Tags []string `xml:"-"`
+	SourceBook string `xml:"-"`
+	Alignment string `xml:"alignment,omitempty"`
+	Ac string `xml:"ac,omitempty"`
+	ArmorClass ArmorClass `xml:"-"`
+	Hp string `xml:"hp,omitempty"`
+	HitPoints HitPoints `xml:"-"`
+	Speed string `xml:"speed,omitempty"`
+	Movement Movement `xml:"-"`
+	Hover bool `xml:"-"`
+	Str string `xml:"str,omitempty"`
+	Dex string `xml:"dex,omitempty"`
+	Con string `xml:"con,omitempty"`
+	Int string `xml:"int,omitempty"`
+	Wis string `xml:"wis,omitempty"`
+	Cha string `xml:"cha,omitempty"`
+	Abilities AbilityScores `xml:"-"`
+	Save string `xml:"save,omitempty"`
+	Skill string `xml:"skill,omitempty"`
+	Skills map[string]int `xml:"-"`
+	Vulnerabilities string `xml:"vulnerable,omitempty"`
+	Resistances string `xml:"resist,omitempty"`
+	DamageImmunity string `xml:"immune,omitempty"`
+	ConditionImmunity string `xml:"conditionImmune,omitempty"`
+	DamageVulnerabilities DamageSet `xml:"-"`
+	DamageResistances DamageSet `xml:"-"`
+	DamageImmunities DamageSet `xml:"-"`
+	ConditionImmunities []string `xml:"-"`
+	Senses string `xml:"senses,omitempty"`
+	SpecialSenses Senses `xml:"-"`
+	Passive string `xml:"passive,omitempty"`
+	PassivePerception int `xml:"-"`
+	Languages string `xml:"languages,omitempty"`
+	Cr string `xml:"cr,omitempty"`
+	ChallengeRating ChallengeRating `xml:"-"`
+	// HasChallengeRating is false when Cr is missing or isn't a rating, like
+	// "varies"; ChallengeRating, XP and ProficiencyBonus are zero then.
+	HasChallengeRating bool `xml:"-"`
+	XP int `xml:"-"`
+	ProficiencyBonus int `xml:"-"`
+
+	Traits []Trait `xml:"trait,omitempty"`
+	Actions []Trait `xml:"action,omitempty"`
+	Reactions []Trait `xml:"reaction,omitempty"`
+	Legendary []Trait `xml:"legendary,omitempty"`
+
+	// The Lion's Den legendary entries, split into their sections.
+	LegendaryIntro []string `xml:"-"`
+	LegendaryActions []Trait `xml:"-"`
+	LegendaryActionsPerRound int `xml:"-"`
+	LairIntro []string `xml:"-"`
+	LairActions []Trait `xml:"-"`
+	RegionalIntro []string `xml:"-"`
+	RegionalEffects []Trait `xml:"-"`
+
+	Spells string `xml:"spells,omitempty"` // included in text
+	Slots string `xml:"slots,omitempty"` // included in text
+	SpellList []string `xml:"-"`
+	Spellcasting *Spellcasting `xml:"-"`
+	InnateSpellcasting *Spellcasting `xml:"-"`
+
+	Description string `xml:"description,omitempty"`
+	Environment string `xml:"environment,omitempty"`
+	Environments []string `xml:"-"`
 
        	Extras []struct {
        	     XMLName xml.Name
+       	     Attrs []xml.Attr `xml:",any,attr" json:",omitempty"`
        	     Content string `xml:",innerxml"`
         } `xml:",any"`
 }
 
+// Parse fills in the structured fields that are derived from the raw XML
+// text. It returns an error for each value that could not be parsed.
+func (m *Monster) Parse() []error {
+	var errs []error
+	m.parseCreatureType()
+	errs = append(errs, m.parseAbilities()...)
+	errs = append(errs, m.parseSaves()...)
+	errs = append(errs, m.parseSkills()...)
+	errs = append(errs, m.parseSenses()...)
+	errs = append(errs, m.parseDamage()...)
+	errs = append(errs, m.parseTraits()...)
+	m.splitLegendary()
+	errs = append(errs, m.parseSpellcasting()...)
+
+	ac, err := ParseArmorClass(m.Ac)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	m.ArmorClass = ac
+
+	mv, hover, err := ParseSpeed(m.Speed)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	m.Movement, m.Hover = mv, hover
+
+	hp, err := ParseHitPoints(m.Hp)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	m.HitPoints = hp
+
+	cr, err := ParseChallengeRating(m.Cr)
+	if err != nil {
+		errs = append(errs, err)
+		m.ChallengeRating, m.HasChallengeRating, m.XP, m.ProficiencyBonus = 0, false, 0, 0
+		return errs
+	}
+	m.ChallengeRating, m.HasChallengeRating = cr, true
+	m.XP = cr.XP()
+	m.ProficiencyBonus = cr.ProficiencyBonus()
+	return errs
+}
+
 func (m *Monster) SizeName() (string) {
-	switch m.Size {
+	return sizeName(m.Size)
+}
+
+func sizeName(size string) (string) {
+	switch size {
 	case "G":
 		return "Gargantuan"
 	case "H":
@@ -228,28 +490,40 @@ func (m *Monster) SizeName() (string) {
 	case "T":
 		return "Tiny"
 	default:
-		return m.Size
+		return size
 	}
 }
 
-func (m *Monster) ShortAc() (string) {
-	s := strings.SplitN(m.Ac, " ", 2)
-	if len(s) > 0 {
-		return s[0]
+// sizeLetter returns the Lion's Den size letter for a size name such as
+// "Medium", or "" if name is not a size.
+func sizeLetter(name string) (string) {
+	for _, size := range []string{"T", "S", "M", "L", "H", "G"} {
+		if strings.EqualFold(name, sizeName(size)) || strings.EqualFold(name, size) {
+			return size
+		}
 	}
-	return m.Ac
+	return ""
+}
+
+func (m *Monster) ShortAc() (string) {
+	return m.ArmorClass.Short()
 }
 
 func (m *Monster) ShortHp() (string) {
-	s := strings.SplitN(m.Hp, " ", 2)
-	if len(s) > 0 {
-		return s[0]
+	return strconv.Itoa(m.HitPoints.Average)
+}
+
+// ChallengeText formats the challenge rating as the books print it, e.g.
+// "2 (450 XP)".
+func (m *Monster) ChallengeText() (string) {
+	if !m.HasChallengeRating {
+		return m.Cr
 	}
-	return m.Hp
+	return m.ChallengeRating.String() + " (" + formatThousands(m.XP) + " XP)"
 }
 
 func (m *Monster) Subtitle() (string) {
-	return m.SizeName() + " " + m.Type + ", " + m.Alignment
+	return m.SizeName() + " " + m.FullType() + ", " + m.Alignment
 }
 
 func (c *Compendium) FindMonster(name string) *Monster {
@@ -285,12 +559,12 @@ const page = `
    <h4>Speed</h4>
    <p>{{.Speed}}</p>
   </property-line>
-  <abilities-block data-str="{{.Str}}"
-                   data-dex="{{.Dex}}"
-                   data-con="{{.Con}}"
-                   data-int="{{.Int}}"
-                   data-wis="{{.Wis}}"
-                   data-cha="{{.Cha}}"></abilities-block>
+  <abilities-block data-str="{{.Abilities.Str}}"
+                   data-dex="{{.Abilities.Dex}}"
+                   data-con="{{.Abilities.Con}}"
+                   data-int="{{.Abilities.Int}}"
+                   data-wis="{{.Abilities.Wis}}"
+                   data-cha="{{.Abilities.Cha}}"></abilities-block>
 {{with .Save}}
   <property-line>
    <h4>Saving Throws</h4>
@@ -330,9 +604,9 @@ const page = `
   <property-line>
    <h4>Senses</h4>
 {{if .Senses}}
-   <p>{{.Senses}}, passive Perception {{.Passive}}</p>
+   <p>{{.Senses}}, passive Perception {{.PassivePerception}}</p>
 {{else}}
-   <p>passive Perception {{.Passive}}</p>
+   <p>passive Perception {{.PassivePerception}}</p>
 {{end}}
   </property-line>
 {{with .Languages}}
@@ -343,13 +617,13 @@ const page = `
 {{end}}
   <property-line>
    <h4>Challenge</h4>
-   <p>{{.Cr}}</p>
+   <p>{{.ChallengeText}}</p>
   </property-line>
  </top-stats>
 
  {{range .Traits}}
  <property-block>
-  <h4>{{.Name}}.</h4>
+  <h4>{{.Heading}}.</h4>
   {{range .FormattedText}}
   <p>{{.}}</p>
   {{end}}
@@ -360,7 +634,7 @@ const page = `
   <h3>Actions</h3>
  {{range .Actions}}
   <property-block>
-    <h4>{{.Name}}.</h4>
+    <h4>{{.Heading}}.</h4>
   {{range .FormattedText}}
   <p>{{.}}</p>
   {{end}}
@@ -372,7 +646,7 @@ const page = `
   <h3>Reactions</h3>
  {{range .Reactions}}
   <property-block>
-    <h4>{{.Name}}.</h4>
+    <h4>{{.Heading}}.</h4>
   {{range .FormattedText}}
   <p>{{.}}</p>
   {{end}}
@@ -380,11 +654,42 @@ const page = `
  {{end}}
  {{end}}
 
- {{if len .Legendary}}
+ {{if or (len .LegendaryActions) (len .LegendaryIntro)}}
   <h3>Legendary Actions</h3>
- {{range .Legendary}}
+ {{range .LegendaryIntro}}
+  <p>{{.}}</p>
+ {{end}}
+ {{range .LegendaryActions}}
   <property-block>
-    <h4>{{.Name}}.</h4>
+    <h4>{{.Heading}}.</h4>
+  {{range .FormattedText}}
+  <p>{{.}}</p>
+  {{end}}
+  </property-block>
+ {{end}}
+ {{end}}
+ {{if or (len .LairActions) (len .LairIntro)}}
+  <h3>Lair Actions</h3>
+ {{range .LairIntro}}
+  <p>{{.}}</p>
+ {{end}}
+ {{range .LairActions}}
+  <property-block>
+    <h4>{{.Heading}}.</h4>
+  {{range .FormattedText}}
+  <p>{{.}}</p>
+  {{end}}
+  </property-block>
+ {{end}}
+ {{end}}
+ {{if or (len .RegionalEffects) (len .RegionalIntro)}}
+  <h3>Regional Effects</h3>
+ {{range .RegionalIntro}}
+  <p>{{.}}</p>
+ {{end}}
+ {{range .RegionalEffects}}
+  <property-block>
+    <h4>{{.Heading}}.</h4>
   {{range .FormattedText}}
   <p>{{.}}</p>
   {{end}}
@@ -399,6 +704,7 @@ const page = `
  {{end}}
 </stat-block>
 {{end}}
+{{- define "HEAD"}}
 <!DOCTYPE html>
 <html>
 <head>
@@ -587,27 +893,6 @@ const page = `
   <tapered-rule></tapered-rule>
 </template><script>
 (function(window, document) {
-  function abilityModifier(abilityScore) {
-    var score = parseInt(abilityScore, 10);
-    return Math.floor((score - 10) / 2);
-  }
-
-  function formattedModifier(abilityModifier) {
-    if (abilityModifier >= 0) {
-      return '+' + abilityModifier;
-    }
-    // This is an en dash, NOT a "normal" dash. The minus sign needs to be more
-    // visible.
-    return '–' + Math.abs(abilityModifier);
-  }
-
-  function abilityText(abilityScore) {
-    return [String(abilityScore),
-            ' (',
-            formattedModifier(abilityModifier(abilityScore)),
-            ')'].join('');
-  }
-
   var elemName = 'abilities-block';
   var thatDoc = document;
   var thisDoc = (thatDoc.currentScript || thatDoc._currentScript).ownerDocument;
@@ -625,8 +910,8 @@ const page = `
         for (var i = 0; i < this.attributes.length; i++) {
           var attribute = this.attributes[i];
           var abilityShortName = attribute.name.split('-')[1];
-          root.getElementById(abilityShortName).textContent =
-             abilityText(attribute.value);
+          // The score and modifier are already formatted on the server.
+          root.getElementById(abilityShortName).textContent = attribute.value;
         }
 
       }
@@ -827,6 +1112,13 @@ const page = `
 })(window, document);
 </script>
 
+{{end}}
+{{- define "MONSTERPAGE"}}
+{{- template "HEAD" .}}
+{{template "STATBLOCK" .}}
+</body></html>
+{{end}}
+{{- template "HEAD" . -}}
 <h1 class="encounter">{{.Name}}</h1>
 <table>
 <tr>
@@ -849,17 +1141,20 @@ const page = `
   <tr class="header">
    <td>Monster</td>
    <td>AC</td>
+   <td>PP</td>
+   <td>Senses</td>
    <td>Conditions</td>
+   <td>Spell Slots</td>
    <td>Current HP</td>
   </tr>
-{{range $i, $m := .Monsters}}
-{{range $num := intarray 0 $m.Quantity}}
+{{range .Tracker}}
   <tr class="content">
-   <td>{{$m.Monster.Name}} {{add $num 1}}</td><td>{{$m.Monster.ShortAc}}</td>
+   <td>{{.Monster.Name}} {{.Num}}</td><td>{{.Monster.ShortAc}}</td>
+   <td>{{.Monster.PassivePerception}}</td><td>{{.Monster.SpecialSenses.Short}}</td>
    <td style="width: 40px; border-bottom: 1px solid black"/>
-   <td style="width: 300px; border-bottom: 1px solid black">{{$m.Monster.ShortHp}}</td>
+   <td>{{.Monster.Spellcasting.SlotsText}}</td>
+   <td style="width: 300px; border-bottom: 1px solid black">{{.Hp}}</td>
   </tr>
-{{end}}
 {{end}}
  </table>
 </div>
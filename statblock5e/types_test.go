@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMixedCompendium = `<?xml version="1.0" encoding="UTF-8"?>
+<compendium>
+	<monster>
+		<name>Goblin</name>
+		<size>S</size>
+		<type>humanoid (goblinoid)</type>
+		<ac>15 (leather armor, shield)</ac>
+		<hp>7 (2d6)</hp>
+		<speed>30 ft.</speed>
+		<str>8</str>
+		<dex>14</dex>
+		<con>10</con>
+		<int>10</int>
+		<wis>8</wis>
+		<cha>8</cha>
+		<cr>1/4</cr>
+		<token size="1" img="tokens/goblin.png">Goblin <b>token</b></token>
+		<action>
+			<name>Scimitar</name>
+			<text>Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 5 (1d6 + 2) slashing damage.</text>
+			<attack>Scimitar|+4|1d6+2</attack>
+		</action>
+	</monster>
+	<spell>
+		<name>Fireball</name>
+		<level>3</level>
+		<school>EV</school>
+		<classes>Sorcerer, Wizard</classes>
+		<text>A bright streak flashes from your pointing finger.</text>
+		<homebrew note="yes">no</homebrew>
+	</spell>
+	<item>
+		<name>Flame Tongue</name>
+		<type>M</type>
+		<detail>rare (requires attunement)</detail>
+		<weight>3</weight>
+		<dmg1>1d8</dmg1>
+		<dmgType>S</dmgType>
+		<modifier category="bonus">melee damage +2d6</modifier>
+		<text>You can use a bonus action to speak this magic sword's command word.</text>
+		<charges max="3"/>
+	</item>
+</compendium>
+`
+
+func TestCompendiumWriteRoundTrip(t *testing.T) {
+	dir := writeTestData(t, map[string]string{"Mixed.xml": testMixedCompendium})
+	path := filepath.Join(dir, "data", "Mixed.xml")
+	c, err := LoadCompendium(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Monsters) != 1 || len(c.Spells) != 1 || len(c.Items) != 1 {
+		t.Fatalf("Loaded %d monsters, %d spells and %d items, want one of each", len(c.Monsters), len(c.Spells), len(c.Items))
+	}
+
+	var first bytes.Buffer
+	if err := c.Write(&first); err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "" {
+		t.Errorf("Write set the version of the compendium to %q", c.Version)
+	}
+	out := first.String()
+	for _, want := range []string{
+		`<compendium version="5">`,
+		`<token size="1" img="tokens/goblin.png">Goblin <b>token</b></token>`,
+		`<attack>Scimitar|+4|1d6+2</attack>`,
+		`<homebrew note="yes">no</homebrew>`,
+		`<modifier category="bonus">melee damage +2d6</modifier>`,
+		`<charges max="3"></charges>`,
+		`<detail>rare (requires attunement)</detail>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Written compendium does not have %q:\n%s", want, out)
+		}
+	}
+	// Derived fields stay out of the XML.
+	for _, derived := range []string{"Abilities", "ArmorClass", "Movement", "Source", "Rarity", "ClassList", "<Id", "Compendium>"} {
+		if strings.Contains(out, derived) {
+			t.Errorf("Written compendium has %q:\n%s", derived, out)
+		}
+	}
+
+	// Writing what was written gives the same file.
+	if err := ioutil.WriteFile(path, first.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := LoadCompendium(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var second bytes.Buffer
+	if err := again.Write(&second); err != nil {
+		t.Fatal(err)
+	}
+	if second.String() != out {
+		t.Errorf("Second write differs:\n%s\nfirst:\n%s", second.String(), out)
+	}
+	if again.Items[0].Rarity != "rare" || !again.Items[0].Attunement || again.Monsters[0].ArmorClass.Value != 15 {
+		t.Errorf("Reloaded item %+v, monster AC %d", again.Items[0], again.Monsters[0].ArmorClass.Value)
+	}
+}
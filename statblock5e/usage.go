@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	parenRe        = regexp.MustCompile(`\s*\(([^)]*)\)`)
+	rechargeRe     = regexp.MustCompile(`(?i)^recharge\s+(\d)(?:\s*[-–—]\s*6)?$`)
+	rechargeRestRe = regexp.MustCompile(`(?i)^recharges?\s+after\s+(?:a\s+|an\s+)?(.+)$`)
+	usesRe         = regexp.MustCompile(`(?i)^(\d+)\s*/\s*(day|short rest|long rest|rest|turn)(\s+each)?$`)
+	costRe         = regexp.MustCompile(`(?i)^costs\s+(\d+)\s+actions?$`)
+)
+
+// parseUsage fills in the usage limits of the trait from the parenthesized
+// parts of its name, e.g. "Fire Breath (Recharge 5–6)". Recognized parts are
+// dropped from DisplayName; anything else is kept.
+func (t *Trait) parseUsage() {
+	t.RechargeMin, t.RechargeRest = 0, ""
+	t.Uses, t.UsesPer, t.UsesEach = 0, "", false
+	t.LegendaryCost = 0
+	t.DisplayName = parenRe.ReplaceAllStringFunc(t.Name, func(paren string) string {
+		parts := strings.Split(parenRe.FindStringSubmatch(paren)[1], ";")
+		var scratch Trait
+		for _, part := range parts {
+			if !scratch.parseUsagePart(strings.TrimSpace(part)) {
+				return paren
+			}
+		}
+		for _, part := range parts {
+			t.parseUsagePart(strings.TrimSpace(part))
+		}
+		return ""
+	})
+	t.DisplayName = strings.TrimSpace(t.DisplayName)
+}
+
+func (t *Trait) parseUsagePart(s string) bool {
+	if p := rechargeRe.FindStringSubmatch(s); p != nil {
+		t.RechargeMin, _ = strconv.Atoi(p[1])
+		return true
+	}
+	if p := rechargeRestRe.FindStringSubmatch(s); p != nil {
+		t.RechargeRest = strings.ToLower(p[1])
+		return true
+	}
+	if p := usesRe.FindStringSubmatch(s); p != nil {
+		t.Uses, _ = strconv.Atoi(p[1])
+		t.UsesPer = strings.ToLower(p[2])
+		t.UsesEach = p[3] != ""
+		return true
+	}
+	if p := costRe.FindStringSubmatch(s); p != nil {
+		t.LegendaryCost, _ = strconv.Atoi(p[1])
+		return true
+	}
+	return false
+}
+
+// Usage formats the usage limits the way the books print them, e.g.
+// "Recharge 5–6" or "3/Day". It is empty for traits without limits.
+func (t Trait) Usage() string {
+	var parts []string
+	switch {
+	case t.RechargeMin == 6:
+		parts = append(parts, "Recharge 6")
+	case t.RechargeMin > 0:
+		parts = append(parts, fmt.Sprintf("Recharge %d–6", t.RechargeMin))
+	}
+	if t.RechargeRest != "" {
+		parts = append(parts, "Recharges after a "+titleCase(t.RechargeRest))
+	}
+	if t.Uses > 0 {
+		s := fmt.Sprintf("%d/%s", t.Uses, titleCase(t.UsesPer))
+		if t.UsesEach {
+			s += " each"
+		}
+		parts = append(parts, s)
+	}
+	if t.LegendaryCost > 1 {
+		parts = append(parts, fmt.Sprintf("Costs %d Actions", t.LegendaryCost))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Heading returns the display name followed by the usage limits.
+func (t Trait) Heading() string {
+	if u := t.Usage(); u != "" {
+		return t.DisplayName + " (" + u + ")"
+	}
+	return t.DisplayName
+}
+
+// titleCase capitalizes each word except short conjunctions and
+// prepositions.
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		if w == "or" || w == "and" || w == "of" {
+			continue
+		}
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		display string
+		usage   string
+	}{
+		{"Fire Breath (Recharge 5–6)", "Fire Breath", "Recharge 5–6"},
+		{"Fire Breath (Recharge 5-6)", "Fire Breath", "Recharge 5–6"},
+		{"Web (Recharge 6)", "Web", "Recharge 6"},
+		{"Shapechanger (Recharges after a Short or Long Rest)", "Shapechanger", "Recharges after a Short or Long Rest"},
+		{"Legendary Resistance (3/Day)", "Legendary Resistance", "3/Day"},
+		{"Innate Spellcasting (1/day each)", "Innate Spellcasting", "1/Day each"},
+		{"Wing Attack (Costs 2 Actions)", "Wing Attack", "Costs 2 Actions"},
+		{"Detect (Costs 1 Action)", "Detect", ""},
+		{"Spore Burst (Recharge 5–6; 1/Day)", "Spore Burst", "Recharge 5–6; 1/Day"},
+		{"Change Shape (Human Form Only)", "Change Shape (Human Form Only)", ""},
+		{"Claws (Bite in Beast Form) (2/Turn)", "Claws (Bite in Beast Form)", "2/Turn"},
+		{"Multiattack", "Multiattack", ""},
+	}
+	for _, tt := range tests {
+		tr := Trait{Name: tt.name}
+		tr.parseUsage()
+		if tr.DisplayName != tt.display || tr.Usage() != tt.usage {
+			t.Errorf("parseUsage(%q) = %q, %q, want %q, %q", tt.name, tr.DisplayName, tr.Usage(), tt.display, tt.usage)
+		}
+		want := tt.display
+		if tt.usage != "" {
+			want += " (" + tt.usage + ")"
+		}
+		if h := tr.Heading(); h != want {
+			t.Errorf("Heading of %q = %q, want %q", tt.name, h, want)
+		}
+	}
+}
+
+func TestParseUsageResets(t *testing.T) {
+	tr := Trait{Name: "Breath (Recharge 5–6)"}
+	tr.parseUsage()
+	tr.Name = "Breath"
+	tr.parseUsage()
+	if tr.RechargeMin != 0 || tr.Usage() != "" {
+		t.Errorf("Usage after renaming = %q, want none", tr.Usage())
+	}
+}